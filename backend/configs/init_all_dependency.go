@@ -16,17 +16,32 @@ var (
 	Authtokens  base_Interface.IToken[string]
 	RbacService rbac.IRbacService
 
-	UserRepos       repository.IUserRepository
-	ClassRepos      repository.IClassRepo
-	AuthPermitRepos repository.IAuthPermitRepo
+	UserRepos             repository.IUserRepository
+	ClassRepos            repository.IClassRepo
+	AuthPermitRepos       repository.IAuthPermitRepo
+	ExerciseRepos         repository.IExerciseRepo
+	ExecutionRepos        repository.IExecutionRepo
+	ExecutionProfileRepos repository.IExecutionProfileRepo
+	RuntimeImageRepos     repository.IRuntimeImageRepo
+	LectureDocumentRepos  repository.ILectureDocumentRepo
 
-	UserServices       service.IUserService
-	ClassServices      service.IClassService
-	AuthPermitServices service.IAuthPermitService
+	UserServices             service.IUserService
+	ClassServices            service.IClassService
+	AuthPermitServices       service.IAuthPermitService
+	ExerciseServices         service.IExerciseService
+	ExecutionServices        service.IExecutionService
+	ExecutionProfileServices service.IExecutionProfileService
+	RuntimeImageServices     service.IRuntimeImageService
+	LectureDocumentServices  service.ILectureDocumentService
 
-	UserApplications       application.IUserApplication
-	ClassApplications      application.IClassApplication
-	AuthPermitApplications application.IAuthPermitApplication
+	UserApplications             application.IUserApplication
+	ClassApplications            application.IClassApplication
+	AuthPermitApplications       application.IAuthPermitApplication
+	ExerciseApplications         application.IExerciseApplication
+	ExecutionApplications        application.IExecutionApplication
+	ExecutionProfileApplications application.IExecutionProfileApplication
+	RuntimeImageApplications     application.IRuntimeImageApplication
+	LectureDocumentApplications  application.ILectureDocumentApplication
 
 	UserHandlers       *handllers.UserHandler
 	ClassHandlers      *handllers.ClassHandler
@@ -57,4 +72,24 @@ func InitALl() {
 	AuthPermitServices = service.NewAuthPermitService(AuthPermitRepos)
 	AuthPermitApplications = application.NewAuthPermitApplication(AuthPermitServices, RbacService)
 	AuthPermitHandlers = handllers.NewAuthPermitHandler(AuthPermitApplications)
+
+	ExerciseRepos = repository.NewExerciseRepo()
+	ExerciseServices = service.NewExerciseService(ExerciseRepos)
+	ExerciseApplications = application.NewExerciseApplication(ExerciseServices)
+
+	ExecutionRepos = repository.NewExecutionRepo()
+	ExecutionServices = service.NewExecutionService(ExecutionRepos)
+	ExecutionApplications = application.NewExecutionApplication(ExecutionServices)
+
+	ExecutionProfileRepos = repository.NewExecutionProfileRepo()
+	ExecutionProfileServices = service.NewExecutionProfileService(ExecutionProfileRepos)
+	ExecutionProfileApplications = application.NewExecutionProfileApplication(ExecutionProfileServices)
+
+	RuntimeImageRepos = repository.NewRuntimeImageRepo()
+	RuntimeImageServices = service.NewRuntimeImageService(RuntimeImageRepos)
+	RuntimeImageApplications = application.NewRuntimeImageApplication(RuntimeImageServices)
+
+	LectureDocumentRepos = repository.NewLectureDocumentRepo()
+	LectureDocumentServices = service.NewLectureDocumentService(LectureDocumentRepos)
+	LectureDocumentApplications = application.NewLectureDocumentApplication(LectureDocumentServices)
 }
@@ -0,0 +1,84 @@
+package application
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/service"
+	"MScProject/core_app/infrastructure"
+	"gorm.io/gorm"
+	"time"
+)
+
+type IExecutionApplication interface {
+	SaveExecution(execution *entities.Execution, artifacts []*entities.ExecutionArtifact) error
+	FindExecutionByExecutionID(executionID string) (*entities.Execution, error)
+	FindExecutionsByLectureID(lectureID uint, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	FindExecutionsByUserZCode(userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	FindExecutionsByLectureIDAndUserZCode(lectureID uint, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	PurgeExecutionsOlderThan(retention time.Duration) (int64, error)
+	GetLectureExecutionStats(lectureID uint, userZCode string) ([]*entities.ExecutionStats, error)
+
+	FindArtifactsByExecutionID(executionID string) ([]*entities.ExecutionArtifact, error)
+	FindArtifact(executionID string, path string) (*entities.ExecutionArtifact, error)
+}
+
+type ExecutionApplication struct {
+	ExecutionService service.IExecutionService
+}
+
+func NewExecutionApplication(executionService service.IExecutionService) *ExecutionApplication {
+	return &ExecutionApplication{
+		ExecutionService: executionService,
+	}
+}
+
+func (e *ExecutionApplication) SaveExecution(execution *entities.Execution, artifacts []*entities.ExecutionArtifact) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return e.ExecutionService.SaveExecution(tx, execution, artifacts) })
+}
+
+func (e *ExecutionApplication) FindExecutionByExecutionID(executionID string) (*entities.Execution, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionService.FindExecutionByExecutionID(db, executionID)
+}
+
+func (e *ExecutionApplication) FindExecutionsByLectureID(lectureID uint, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionService.FindExecutionsByLectureID(db, lectureID, from, to, limit)
+}
+
+func (e *ExecutionApplication) FindExecutionsByUserZCode(userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionService.FindExecutionsByUserZCode(db, userZCode, from, to, limit)
+}
+
+func (e *ExecutionApplication) FindExecutionsByLectureIDAndUserZCode(lectureID uint, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionService.FindExecutionsByLectureIDAndUserZCode(db, lectureID, userZCode, from, to, limit)
+}
+
+func (e *ExecutionApplication) PurgeExecutionsOlderThan(retention time.Duration) (int64, error) {
+	db := infrastructure.GetDB()
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		deleted, err = e.ExecutionService.PurgeExecutionsOlderThan(tx, retention)
+		return err
+	})
+	return deleted, err
+}
+
+func (e *ExecutionApplication) GetLectureExecutionStats(lectureID uint, userZCode string) ([]*entities.ExecutionStats, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionService.GetLectureExecutionStats(db, lectureID, userZCode)
+}
+
+func (e *ExecutionApplication) FindArtifactsByExecutionID(executionID string) ([]*entities.ExecutionArtifact, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionService.FindArtifactsByExecutionID(db, executionID)
+}
+
+func (e *ExecutionApplication) FindArtifact(executionID string, path string) (*entities.ExecutionArtifact, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionService.FindArtifact(db, executionID, path)
+}
@@ -0,0 +1,74 @@
+package application
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/service"
+	"MScProject/core_app/infrastructure"
+	"gorm.io/gorm"
+)
+
+type IExecutionProfileApplication interface {
+	CreateExecutionProfile(profile *entities.ExecutionProfile) error
+	UpdateExecutionProfile(profile *entities.ExecutionProfile) error
+	DeleteExecutionProfile(profileID uint) error
+	FindExecutionProfileByID(profileID uint) (*entities.ExecutionProfile, error)
+	FindExecutionProfilesByClassID(classID uint) ([]*entities.ExecutionProfile, error)
+	FindExecutionProfilesForLecture(classID uint, lectureID uint) ([]*entities.ExecutionProfile, error)
+
+	SaveClassPolicy(classPolicy *entities.ClassPolicy) error
+	FindClassPolicy(classID uint) (*entities.ClassPolicy, error)
+}
+
+type ExecutionProfileApplication struct {
+	ExecutionProfileService service.IExecutionProfileService
+}
+
+func NewExecutionProfileApplication(executionProfileService service.IExecutionProfileService) *ExecutionProfileApplication {
+	return &ExecutionProfileApplication{
+		ExecutionProfileService: executionProfileService,
+	}
+}
+
+func (e *ExecutionProfileApplication) CreateExecutionProfile(profile *entities.ExecutionProfile) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return e.ExecutionProfileService.CreateExecutionProfile(tx, profile) })
+}
+
+func (e *ExecutionProfileApplication) UpdateExecutionProfile(profile *entities.ExecutionProfile) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return e.ExecutionProfileService.UpdateExecutionProfile(tx, profile) })
+}
+
+func (e *ExecutionProfileApplication) DeleteExecutionProfile(profileID uint) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return e.ExecutionProfileService.DeleteExecutionProfile(tx, profileID) })
+}
+
+func (e *ExecutionProfileApplication) FindExecutionProfileByID(profileID uint) (*entities.ExecutionProfile, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionProfileService.FindExecutionProfileByID(db, profileID)
+}
+
+func (e *ExecutionProfileApplication) FindExecutionProfilesByClassID(classID uint) ([]*entities.ExecutionProfile, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionProfileService.FindExecutionProfilesByClassID(db, classID)
+}
+
+func (e *ExecutionProfileApplication) FindExecutionProfilesForLecture(classID uint, lectureID uint) ([]*entities.ExecutionProfile, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionProfileService.FindExecutionProfilesForLecture(db, classID, lectureID)
+}
+
+func (e *ExecutionProfileApplication) SaveClassPolicy(classPolicy *entities.ClassPolicy) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return e.ExecutionProfileService.SaveClassPolicy(tx, classPolicy) })
+}
+
+func (e *ExecutionProfileApplication) FindClassPolicy(classID uint) (*entities.ClassPolicy, error) {
+	db := infrastructure.GetDB()
+	return e.ExecutionProfileService.FindClassPolicy(db, classID)
+}
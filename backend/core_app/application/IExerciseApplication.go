@@ -0,0 +1,75 @@
+package application
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/service"
+	"MScProject/core_app/infrastructure"
+	"gorm.io/gorm"
+)
+
+type IExerciseApplication interface {
+	CreateExercise(lectureID uint, title string, description string, language string, createdByZCodeID uint64, testCases []*entities.ExerciseTestCase) (*entities.Exercise, error)
+	DeleteExercise(exerciseID uint) error
+	FindExerciseByID(exerciseID uint) (*entities.Exercise, error)
+	FindExercisesByLectureID(lectureID uint) ([]*entities.Exercise, error)
+	FindTestCasesByExerciseID(exerciseID uint) ([]*entities.ExerciseTestCase, error)
+
+	SaveSubmission(submission *entities.ExerciseSubmission) error
+	FindSubmissionsByExerciseID(exerciseID uint) ([]*entities.ExerciseSubmission, error)
+}
+
+type ExerciseApplication struct {
+	ExerciseService service.IExerciseService
+}
+
+func NewExerciseApplication(exerciseService service.IExerciseService) *ExerciseApplication {
+	return &ExerciseApplication{
+		ExerciseService: exerciseService,
+	}
+}
+
+func (e *ExerciseApplication) CreateExercise(lectureID uint, title string, description string, language string, createdByZCodeID uint64, testCases []*entities.ExerciseTestCase) (*entities.Exercise, error) {
+	db := infrastructure.GetDB()
+	var exercise *entities.Exercise
+	var err error
+	errs := db.Transaction(func(tx *gorm.DB) error {
+		exercise, err = e.ExerciseService.CreateExercise(tx, lectureID, title, description, language, createdByZCodeID, testCases)
+		return err
+	})
+	if errs != nil {
+		return nil, errs
+	}
+	return exercise, nil
+}
+
+func (e *ExerciseApplication) DeleteExercise(exerciseID uint) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return e.ExerciseService.DeleteExercise(tx, exerciseID) })
+}
+
+func (e *ExerciseApplication) FindExerciseByID(exerciseID uint) (*entities.Exercise, error) {
+	db := infrastructure.GetDB()
+	return e.ExerciseService.FindExerciseByID(db, exerciseID)
+}
+
+func (e *ExerciseApplication) FindExercisesByLectureID(lectureID uint) ([]*entities.Exercise, error) {
+	db := infrastructure.GetDB()
+	return e.ExerciseService.FindExercisesByLectureID(db, lectureID)
+}
+
+func (e *ExerciseApplication) FindTestCasesByExerciseID(exerciseID uint) ([]*entities.ExerciseTestCase, error) {
+	db := infrastructure.GetDB()
+	return e.ExerciseService.FindTestCasesByExerciseID(db, exerciseID)
+}
+
+func (e *ExerciseApplication) SaveSubmission(submission *entities.ExerciseSubmission) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return e.ExerciseService.SaveSubmission(tx, submission) })
+}
+
+func (e *ExerciseApplication) FindSubmissionsByExerciseID(exerciseID uint) ([]*entities.ExerciseSubmission, error) {
+	db := infrastructure.GetDB()
+	return e.ExerciseService.FindSubmissionsByExerciseID(db, exerciseID)
+}
@@ -0,0 +1,40 @@
+package application
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/service"
+	"MScProject/core_app/infrastructure"
+	"gorm.io/gorm"
+)
+
+type ILectureDocumentApplication interface {
+	SaveLectureDocument(document *entities.LectureDocument) error
+	FindLectureDocument(lectureID uint, documentKey string) (*entities.LectureDocument, error)
+	FindLectureDocumentsByLectureID(lectureID uint) ([]*entities.LectureDocument, error)
+}
+
+type LectureDocumentApplication struct {
+	LectureDocumentService service.ILectureDocumentService
+}
+
+func NewLectureDocumentApplication(lectureDocumentService service.ILectureDocumentService) *LectureDocumentApplication {
+	return &LectureDocumentApplication{
+		LectureDocumentService: lectureDocumentService,
+	}
+}
+
+func (l *LectureDocumentApplication) SaveLectureDocument(document *entities.LectureDocument) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return l.LectureDocumentService.SaveLectureDocument(tx, document) })
+}
+
+func (l *LectureDocumentApplication) FindLectureDocument(lectureID uint, documentKey string) (*entities.LectureDocument, error) {
+	db := infrastructure.GetDB()
+	return l.LectureDocumentService.FindLectureDocument(db, lectureID, documentKey)
+}
+
+func (l *LectureDocumentApplication) FindLectureDocumentsByLectureID(lectureID uint) ([]*entities.LectureDocument, error) {
+	db := infrastructure.GetDB()
+	return l.LectureDocumentService.FindLectureDocumentsByLectureID(db, lectureID)
+}
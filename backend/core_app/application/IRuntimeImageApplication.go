@@ -0,0 +1,81 @@
+package application
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/service"
+	"MScProject/core_app/infrastructure"
+	"gorm.io/gorm"
+)
+
+type IRuntimeImageApplication interface {
+	CreateRuntimeImage(runtimeImage *entities.RuntimeImage) error
+	UpdateRuntimeImage(runtimeImage *entities.RuntimeImage) error
+	DeleteRuntimeImage(runtimeImageID uint) error
+	FindRuntimeImageByID(runtimeImageID uint) (*entities.RuntimeImage, error)
+	FindAllRuntimeImages() ([]*entities.RuntimeImage, error)
+
+	SaveClassRuntime(classRuntime *entities.ClassRuntime) error
+	DeleteClassRuntime(classID uint, language string) error
+	FindClassRuntime(classID uint, language string) (*entities.ClassRuntime, error)
+	FindClassRuntimesByClassID(classID uint) ([]*entities.ClassRuntime, error)
+}
+
+type RuntimeImageApplication struct {
+	RuntimeImageService service.IRuntimeImageService
+}
+
+func NewRuntimeImageApplication(runtimeImageService service.IRuntimeImageService) *RuntimeImageApplication {
+	return &RuntimeImageApplication{
+		RuntimeImageService: runtimeImageService,
+	}
+}
+
+func (r *RuntimeImageApplication) CreateRuntimeImage(runtimeImage *entities.RuntimeImage) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return r.RuntimeImageService.CreateRuntimeImage(tx, runtimeImage) })
+}
+
+func (r *RuntimeImageApplication) UpdateRuntimeImage(runtimeImage *entities.RuntimeImage) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return r.RuntimeImageService.UpdateRuntimeImage(tx, runtimeImage) })
+}
+
+func (r *RuntimeImageApplication) DeleteRuntimeImage(runtimeImageID uint) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return r.RuntimeImageService.DeleteRuntimeImage(tx, runtimeImageID) })
+}
+
+func (r *RuntimeImageApplication) FindRuntimeImageByID(runtimeImageID uint) (*entities.RuntimeImage, error) {
+	db := infrastructure.GetDB()
+	return r.RuntimeImageService.FindRuntimeImageByID(db, runtimeImageID)
+}
+
+func (r *RuntimeImageApplication) FindAllRuntimeImages() ([]*entities.RuntimeImage, error) {
+	db := infrastructure.GetDB()
+	return r.RuntimeImageService.FindAllRuntimeImages(db)
+}
+
+func (r *RuntimeImageApplication) SaveClassRuntime(classRuntime *entities.ClassRuntime) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return r.RuntimeImageService.SaveClassRuntime(tx, classRuntime) })
+}
+
+func (r *RuntimeImageApplication) DeleteClassRuntime(classID uint, language string) error {
+	db := infrastructure.GetDB()
+	return db.Transaction(
+		func(tx *gorm.DB) error { return r.RuntimeImageService.DeleteClassRuntime(tx, classID, language) })
+}
+
+func (r *RuntimeImageApplication) FindClassRuntime(classID uint, language string) (*entities.ClassRuntime, error) {
+	db := infrastructure.GetDB()
+	return r.RuntimeImageService.FindClassRuntime(db, classID, language)
+}
+
+func (r *RuntimeImageApplication) FindClassRuntimesByClassID(classID uint) ([]*entities.ClassRuntime, error) {
+	db := infrastructure.GetDB()
+	return r.RuntimeImageService.FindClassRuntimesByClassID(db, classID)
+}
@@ -0,0 +1,58 @@
+package entities
+
+import "time"
+
+type Execution struct {
+	BaseEntity
+	ExecutionID       string     `gorm:"size:64;uniqueIndex" json:"execution_id"`
+	LectureID         uint       `json:"lecture_id"`
+	UserZCode         string     `gorm:"size:64" json:"user_zcode"`
+	Language          string     `gorm:"size:50" json:"language"`
+	Code              string     `gorm:"type:text" json:"code"`
+	Status            string     `gorm:"size:32" json:"status"`
+	Output            string     `gorm:"type:mediumtext" json:"output"`
+	Error             string     `gorm:"type:text" json:"error"`
+	ExitCode          int        `json:"exit_code"`
+	StdinExhausted    bool       `json:"stdin_exhausted"`
+	CompileStatus     string     `gorm:"size:32" json:"compile_status"`
+	CompileOutput     string     `gorm:"type:text" json:"compile_output"`
+	CompileDurationMS int64      `json:"compile_duration_ms" gorm:"column:compile_duration_ms"`
+	Diagnostics       string     `gorm:"type:text" json:"diagnostics"`
+	DurationMS        int64      `json:"duration_ms" gorm:"column:duration_ms"`
+	PeakMemoryBytes   int64      `json:"peak_memory_bytes"`
+	CPUTimeMS         int64      `json:"cpu_time_ms" gorm:"column:cpu_time_ms"`
+	OOMKilled         bool       `json:"oom_killed" gorm:"column:oom_killed"`
+	TimedOut          bool       `json:"timed_out"`
+	OutputTruncated   bool       `json:"output_truncated"`
+	OutputBytes       int64      `json:"output_bytes"`
+	QueuedAt          *time.Time `json:"queued_at"`
+	ExecutedAt        *time.Time `json:"executed_at"`
+}
+
+func (Execution) TableName() string {
+	return "executions"
+}
+
+// ExecutionStats is one user's aggregate over the executions table, it has no table of its own.
+type ExecutionStats struct {
+	UserZCode    string     `json:"user_zcode"`
+	RunCount     int64      `json:"run_count"`
+	SuccessCount int64      `json:"success_count"`
+	ErrorCount   int64      `json:"error_count"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastError    string     `json:"last_error" gorm:"-"`
+	LastErrorAt  *time.Time `json:"last_error_at" gorm:"-"`
+}
+
+type ExecutionArtifact struct {
+	BaseEntity
+	ExecutionID string `gorm:"size:64;index" json:"execution_id"`
+	Path        string `gorm:"size:255" json:"path"`
+	MIMEType    string `gorm:"size:100;column:mime_type" json:"mime_type"`
+	Size        int64  `json:"size"`
+	Content     []byte `gorm:"type:mediumblob" json:"-"`
+}
+
+func (ExecutionArtifact) TableName() string {
+	return "execution_artifacts"
+}
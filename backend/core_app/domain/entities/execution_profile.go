@@ -0,0 +1,33 @@
+package entities
+
+// ExecutionProfile overrides the execution limits for a class, or for a single
+// lecture of it. Zero limits keep the value of the built-in configuration.
+type ExecutionProfile struct {
+	BaseEntity
+	Name             string  `gorm:"size:255" json:"name"`
+	ClassID          uint    `json:"class_id"`
+	LectureID        uint    `json:"lecture_id"`               // 0 applies to every lecture of the class
+	Language         string  `gorm:"size:50" json:"language"`  // empty applies to every language
+	UserRole         string  `gorm:"size:50" json:"user_role"` // empty applies to every class role
+	TimeoutSeconds   int     `json:"timeout_seconds"`
+	MemoryLimitMB    int     `json:"memory_limit_mb" gorm:"column:memory_limit_mb"`
+	CPULimit         float64 `json:"cpu_limit" gorm:"column:cpu_limit"`
+	OutputLimitKB    int     `json:"output_limit_kb" gorm:"column:output_limit_kb"`
+	AllowedPackages  string  `gorm:"type:text" json:"allowed_packages"` // comma separated, empty keeps the class policy
+	CreatedByZCodeID uint64  `json:"created_by_zcode_id" gorm:"column:created_by_zcode_id"`
+}
+
+func (ExecutionProfile) TableName() string {
+	return "execution_profiles"
+}
+
+// ClassPolicy is the code policy a class chose for its lectures.
+type ClassPolicy struct {
+	BaseEntity
+	ClassID    uint   `json:"class_id"`
+	PolicyName string `gorm:"size:50" json:"policy_name"`
+}
+
+func (ClassPolicy) TableName() string {
+	return "class_policies"
+}
@@ -0,0 +1,46 @@
+package entities
+
+type Exercise struct {
+	BaseEntity
+	LectureID        uint   `json:"lecture_id"`
+	Title            string `gorm:"size:255" json:"title"`
+	Description      string `gorm:"type:text" json:"description"`
+	Language         string `gorm:"size:50" json:"language"`
+	CreatedByZCodeID uint64 `json:"created_by_zcode_id" gorm:"column:created_by_zcode_id"`
+}
+
+func (Exercise) TableName() string {
+	return "exercises"
+}
+
+type ExerciseTestCase struct {
+	BaseEntity
+	ExerciseID     uint   `json:"exercise_id"`
+	Name           string `gorm:"size:255" json:"name"`
+	Stdin          string `gorm:"type:text" json:"stdin"`
+	ExpectedOutput string `gorm:"type:text" json:"expected_output"`
+	TestCode       string `gorm:"type:text" json:"test_code"`
+	Hidden         bool   `json:"hidden"`
+	Weight         int    `json:"weight"`
+}
+
+func (ExerciseTestCase) TableName() string {
+	return "exercise_test_cases"
+}
+
+type ExerciseSubmission struct {
+	BaseEntity
+	ExerciseID  uint    `json:"exercise_id"`
+	LectureID   uint    `json:"lecture_id"`
+	UserZCodeID uint64  `json:"user_zcode_id" gorm:"column:user_zcode_id"`
+	Code        string  `gorm:"type:text" json:"code"`
+	Score       float64 `json:"score"`
+	PassedCount int     `json:"passed_count"`
+	TotalCount  int     `json:"total_count"`
+	DurationMS  int64   `json:"duration_ms" gorm:"column:duration_ms"`
+	Report      string  `gorm:"type:mediumtext" json:"report"`
+}
+
+func (ExerciseSubmission) TableName() string {
+	return "exercise_submissions"
+}
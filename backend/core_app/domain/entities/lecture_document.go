@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// LectureDocument is the saved state of a collaborative Yjs document of a
+// lecture, e.g. "teacher-code" or "student-<zcode>".
+type LectureDocument struct {
+	BaseEntity
+	LectureID   uint       `json:"lecture_id"`
+	DocumentKey string     `gorm:"size:100" json:"document_key"`
+	State       []byte     `gorm:"type:mediumblob" json:"-"` // merged Yjs update of the whole document
+	Content     string     `gorm:"type:mediumtext" json:"content"`
+	UpdateCount uint64     `json:"update_count"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	FinalizedAt *time.Time `json:"finalized_at"` // set once the lecture has ended
+}
+
+func (LectureDocument) TableName() string {
+	return "lecture_documents"
+}
@@ -0,0 +1,29 @@
+package entities
+
+// RuntimeImage is a sandbox image an administrator prepared for a language,
+// e.g. Python with numpy and pandas preinstalled.
+type RuntimeImage struct {
+	BaseEntity
+	Name             string `gorm:"size:100;unique" json:"name"`
+	Language         string `gorm:"size:50" json:"language"`
+	Image            string `gorm:"size:255" json:"image"`
+	Description      string `gorm:"type:text" json:"description"`
+	Packages         string `gorm:"type:text" json:"packages"` // comma separated modules the image adds
+	CreatedByZCodeID uint64 `json:"created_by_zcode_id" gorm:"column:created_by_zcode_id"`
+}
+
+func (RuntimeImage) TableName() string {
+	return "runtime_images"
+}
+
+// ClassRuntime is the runtime image a class uses for a language.
+type ClassRuntime struct {
+	BaseEntity
+	ClassID        uint   `json:"class_id"`
+	Language       string `gorm:"size:50" json:"language"`
+	RuntimeImageID uint   `json:"runtime_image_id"`
+}
+
+func (ClassRuntime) TableName() string {
+	return "class_runtimes"
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type IExecutionProfileRepo interface {
+	CreateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error
+	UpdateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error
+	DeleteExecutionProfile(db *gorm.DB, profileID uint) error
+	FindExecutionProfileByID(db *gorm.DB, profileID uint) (*entities.ExecutionProfile, error)
+	FindExecutionProfilesByClassID(db *gorm.DB, classID uint) ([]*entities.ExecutionProfile, error)
+	FindExecutionProfilesForLecture(db *gorm.DB, classID uint, lectureID uint) ([]*entities.ExecutionProfile, error)
+
+	SaveClassPolicy(db *gorm.DB, classPolicy *entities.ClassPolicy) error
+	FindClassPolicy(db *gorm.DB, classID uint) (*entities.ClassPolicy, error)
+}
+
+type ExecutionProfileRepo struct {
+}
+
+func NewExecutionProfileRepo() *ExecutionProfileRepo {
+	return &ExecutionProfileRepo{}
+}
+
+func (e *ExecutionProfileRepo) CreateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error {
+	err := db.Create(profile).Error
+	if err != nil {
+		return errors.New("Database: failed to create the execution profile")
+	}
+	return nil
+}
+
+func (e *ExecutionProfileRepo) UpdateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error {
+	err := db.Save(profile).Error
+	if err != nil {
+		return errors.New("Database: failed to update the execution profile")
+	}
+	return nil
+}
+
+func (e *ExecutionProfileRepo) DeleteExecutionProfile(db *gorm.DB, profileID uint) error {
+	err := db.Delete(&entities.ExecutionProfile{}, profileID).Error
+	if err != nil {
+		return errors.New("Database: failed to delete the execution profile")
+	}
+	return nil
+}
+
+func (e *ExecutionProfileRepo) FindExecutionProfileByID(db *gorm.DB, profileID uint) (*entities.ExecutionProfile, error) {
+	var profile entities.ExecutionProfile
+	err := db.Where("ID=?", profileID).First(&profile).Error
+	if err != nil {
+		return nil, errors.New("Database: execution profile not found")
+	}
+	return &profile, nil
+}
+
+func (e *ExecutionProfileRepo) FindExecutionProfilesByClassID(db *gorm.DB, classID uint) ([]*entities.ExecutionProfile, error) {
+	var profiles []*entities.ExecutionProfile
+	err := db.Where("class_id=?", classID).Order("lecture_id, id").Find(&profiles).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find execution profiles")
+	}
+	return profiles, nil
+}
+
+// FindExecutionProfilesForLecture returns the class-wide profiles and those of the lecture.
+func (e *ExecutionProfileRepo) FindExecutionProfilesForLecture(db *gorm.DB, classID uint, lectureID uint) ([]*entities.ExecutionProfile, error) {
+	var profiles []*entities.ExecutionProfile
+	err := db.Where("class_id=? AND lecture_id IN (0, ?)", classID, lectureID).Order("id").Find(&profiles).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find execution profiles")
+	}
+	return profiles, nil
+}
+
+// SaveClassPolicy replaces the class's choice.
+func (e *ExecutionProfileRepo) SaveClassPolicy(db *gorm.DB, classPolicy *entities.ClassPolicy) error {
+	err := db.Where("class_id=?", classPolicy.ClassID).Delete(&entities.ClassPolicy{}).Error
+	if err != nil {
+		return errors.New("Database: failed to replace the class policy")
+	}
+	err = db.Create(classPolicy).Error
+	if err != nil {
+		return errors.New("Database: failed to save the class policy")
+	}
+	return nil
+}
+
+func (e *ExecutionProfileRepo) FindClassPolicy(db *gorm.DB, classID uint) (*entities.ClassPolicy, error) {
+	var classPolicy entities.ClassPolicy
+	err := db.Where("class_id=?", classID).First(&classPolicy).Error
+	if err != nil {
+		return nil, errors.New("Database: class policy not found")
+	}
+	return &classPolicy, nil
+}
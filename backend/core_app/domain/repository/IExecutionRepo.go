@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type IExecutionRepo interface {
+	CreateExecution(db *gorm.DB, execution *entities.Execution) error
+	FindExecutionByExecutionID(db *gorm.DB, executionID string) (*entities.Execution, error)
+	FindExecutionsByLectureID(db *gorm.DB, lectureID uint, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	FindExecutionsByUserZCode(db *gorm.DB, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	FindExecutionsByLectureIDAndUserZCode(db *gorm.DB, lectureID uint, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	DeleteExecutionsBefore(db *gorm.DB, before time.Time) (int64, error)
+
+	CreateArtifact(db *gorm.DB, artifact *entities.ExecutionArtifact) error
+	FindArtifactsByExecutionID(db *gorm.DB, executionID string) ([]*entities.ExecutionArtifact, error)
+	FindArtifact(db *gorm.DB, executionID string, path string) (*entities.ExecutionArtifact, error)
+
+	SummarizeExecutionsByLectureID(db *gorm.DB, lectureID uint, userZCode string) ([]*entities.ExecutionStats, error)
+	FindLastErrorsByLectureID(db *gorm.DB, lectureID uint, userZCode string) ([]*entities.Execution, error)
+}
+
+// ExecutionErrorStatuses are the statuses counted as a failed run in the statistics.
+var ExecutionErrorStatuses = []string{"failed", "timeout", "compile_error", "memory_exceeded", "output_limit"}
+
+type ExecutionRepo struct {
+}
+
+func NewExecutionRepo() *ExecutionRepo {
+	return &ExecutionRepo{}
+}
+
+func (e *ExecutionRepo) CreateExecution(db *gorm.DB, execution *entities.Execution) error {
+	err := db.Create(execution).Error
+	if err != nil {
+		return errors.New("Database: failed to save the execution")
+	}
+	return nil
+}
+
+func (e *ExecutionRepo) FindExecutionByExecutionID(db *gorm.DB, executionID string) (*entities.Execution, error) {
+	var execution entities.Execution
+	err := db.Where("execution_id=?", executionID).First(&execution).Error
+	if err != nil {
+		return nil, errors.New("Database: execution not found")
+	}
+	return &execution, nil
+}
+
+func (e *ExecutionRepo) FindExecutionsByLectureID(db *gorm.DB, lectureID uint, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	var executions []*entities.Execution
+	query := executionTimeRange(db.Where("lecture_id=?", lectureID), from, to)
+	err := query.Order("created_at desc").Limit(limit).Find(&executions).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find executions")
+	}
+	return executions, nil
+}
+
+func (e *ExecutionRepo) FindExecutionsByUserZCode(db *gorm.DB, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	var executions []*entities.Execution
+	query := executionTimeRange(db.Where("user_zcode=?", userZCode), from, to)
+	err := query.Order("created_at desc").Limit(limit).Find(&executions).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find executions")
+	}
+	return executions, nil
+}
+
+func (e *ExecutionRepo) FindExecutionsByLectureIDAndUserZCode(db *gorm.DB, lectureID uint, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	var executions []*entities.Execution
+	query := executionTimeRange(db.Where("lecture_id=? AND user_zcode=?", lectureID, userZCode), from, to)
+	err := query.Order("created_at desc").Limit(limit).Find(&executions).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find executions")
+	}
+	return executions, nil
+}
+
+func (e *ExecutionRepo) DeleteExecutionsBefore(db *gorm.DB, before time.Time) (int64, error) {
+	err := db.Where("created_at<?", before).Delete(&entities.ExecutionArtifact{}).Error
+	if err != nil {
+		return 0, errors.New("Database: failed to delete old artifacts")
+	}
+	result := db.Where("created_at<?", before).Delete(&entities.Execution{})
+	if result.Error != nil {
+		return 0, errors.New("Database: failed to delete old executions")
+	}
+	return result.RowsAffected, nil
+}
+
+func (e *ExecutionRepo) CreateArtifact(db *gorm.DB, artifact *entities.ExecutionArtifact) error {
+	err := db.Create(artifact).Error
+	if err != nil {
+		return errors.New("Database: failed to save the artifact")
+	}
+	return nil
+}
+
+// FindArtifactsByExecutionID lists the artifacts without loading their content.
+func (e *ExecutionRepo) FindArtifactsByExecutionID(db *gorm.DB, executionID string) ([]*entities.ExecutionArtifact, error) {
+	var artifacts []*entities.ExecutionArtifact
+	err := db.Omit("content").Where("execution_id=?", executionID).Order("id").Find(&artifacts).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find artifacts")
+	}
+	return artifacts, nil
+}
+
+func (e *ExecutionRepo) FindArtifact(db *gorm.DB, executionID string, path string) (*entities.ExecutionArtifact, error) {
+	var artifact entities.ExecutionArtifact
+	err := db.Where("execution_id=? AND path=?", executionID, path).First(&artifact).Error
+	if err != nil {
+		return nil, errors.New("Database: artifact not found")
+	}
+	return &artifact, nil
+}
+
+// SummarizeExecutionsByLectureID groups a lecture's runs per user; an empty userZCode means every user.
+func (e *ExecutionRepo) SummarizeExecutionsByLectureID(db *gorm.DB, lectureID uint, userZCode string) ([]*entities.ExecutionStats, error) {
+	var stats []*entities.ExecutionStats
+	query := db.Model(&entities.Execution{}).
+		Select("user_zcode, COUNT(*) AS run_count, "+
+			"SUM(CASE WHEN status='completed' THEN 1 ELSE 0 END) AS success_count, "+
+			"SUM(CASE WHEN status IN ? THEN 1 ELSE 0 END) AS error_count, "+
+			"MAX(created_at) AS last_run_at", ExecutionErrorStatuses).
+		Where("lecture_id=?", lectureID)
+	if userZCode != "" {
+		query = query.Where("user_zcode=?", userZCode)
+	}
+	err := query.Group("user_zcode").Scan(&stats).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to summarize executions")
+	}
+	return stats, nil
+}
+
+// FindLastErrorsByLectureID returns the latest failed run of each user in a lecture.
+func (e *ExecutionRepo) FindLastErrorsByLectureID(db *gorm.DB, lectureID uint, userZCode string) ([]*entities.Execution, error) {
+	var executions []*entities.Execution
+	latest := db.Model(&entities.Execution{}).Select("MAX(id)").
+		Where("lecture_id=? AND status IN ?", lectureID, ExecutionErrorStatuses)
+	if userZCode != "" {
+		latest = latest.Where("user_zcode=?", userZCode)
+	}
+	err := db.Where("id IN (?)", latest.Group("user_zcode")).Find(&executions).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find last errors")
+	}
+	return executions, nil
+}
+
+// executionTimeRange narrows a query to [from, to); a zero bound leaves that side open.
+func executionTimeRange(query *gorm.DB, from time.Time, to time.Time) *gorm.DB {
+	if !from.IsZero() {
+		query = query.Where("created_at>=?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("created_at<?", to)
+	}
+	return query
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type IExerciseRepo interface {
+	CreateExercise(db *gorm.DB, exercise *entities.Exercise) error
+	DeleteExercise(db *gorm.DB, exerciseID uint) error
+	FindExerciseByID(db *gorm.DB, exerciseID uint) (*entities.Exercise, error)
+	FindExercisesByLectureID(db *gorm.DB, lectureID uint) ([]*entities.Exercise, error)
+
+	AddTestCase(db *gorm.DB, testCase *entities.ExerciseTestCase) error
+	FindTestCasesByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseTestCase, error)
+
+	CreateSubmission(db *gorm.DB, submission *entities.ExerciseSubmission) error
+	FindSubmissionsByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseSubmission, error)
+}
+
+type ExerciseRepo struct {
+}
+
+func NewExerciseRepo() *ExerciseRepo {
+	return &ExerciseRepo{}
+}
+
+func (e *ExerciseRepo) CreateExercise(db *gorm.DB, exercise *entities.Exercise) error {
+	err := db.Create(exercise).Error
+	if err != nil {
+		return errors.New("Database: failed to create the exercise")
+	}
+	return nil
+}
+
+func (e *ExerciseRepo) DeleteExercise(db *gorm.DB, exerciseID uint) error {
+	err := db.Delete(&entities.Exercise{}, exerciseID).Error
+	if err != nil {
+		return errors.New("Database: failed to delete the exercise")
+	}
+	return nil
+}
+
+func (e *ExerciseRepo) FindExerciseByID(db *gorm.DB, exerciseID uint) (*entities.Exercise, error) {
+	var exercise entities.Exercise
+	err := db.Where("ID=?", exerciseID).First(&exercise).Error
+	if err != nil {
+		return nil, errors.New("Database: exercise not found")
+	}
+	return &exercise, nil
+}
+
+func (e *ExerciseRepo) FindExercisesByLectureID(db *gorm.DB, lectureID uint) ([]*entities.Exercise, error) {
+	var exercises []*entities.Exercise
+	err := db.Where("lecture_id=?", lectureID).Find(&exercises).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find exercises")
+	}
+	return exercises, nil
+}
+
+func (e *ExerciseRepo) AddTestCase(db *gorm.DB, testCase *entities.ExerciseTestCase) error {
+	err := db.Create(testCase).Error
+	if err != nil {
+		return errors.New("Database: failed to add the test case")
+	}
+	return nil
+}
+
+func (e *ExerciseRepo) FindTestCasesByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseTestCase, error) {
+	var testCases []*entities.ExerciseTestCase
+	err := db.Where("exercise_id=?", exerciseID).Order("id").Find(&testCases).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find test cases")
+	}
+	return testCases, nil
+}
+
+func (e *ExerciseRepo) CreateSubmission(db *gorm.DB, submission *entities.ExerciseSubmission) error {
+	err := db.Create(submission).Error
+	if err != nil {
+		return errors.New("Database: failed to save the submission")
+	}
+	return nil
+}
+
+func (e *ExerciseRepo) FindSubmissionsByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseSubmission, error) {
+	var submissions []*entities.ExerciseSubmission
+	err := db.Where("exercise_id=?", exerciseID).Order("created_at").Find(&submissions).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find submissions")
+	}
+	return submissions, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type ILectureDocumentRepo interface {
+	SaveLectureDocument(db *gorm.DB, document *entities.LectureDocument) error
+	FindLectureDocument(db *gorm.DB, lectureID uint, documentKey string) (*entities.LectureDocument, error)
+	FindLectureDocumentsByLectureID(db *gorm.DB, lectureID uint) ([]*entities.LectureDocument, error)
+}
+
+type LectureDocumentRepo struct {
+}
+
+func NewLectureDocumentRepo() *LectureDocumentRepo {
+	return &LectureDocumentRepo{}
+}
+
+// SaveLectureDocument creates the document or overwrites the saved one of the
+// same lecture and key.
+func (r *LectureDocumentRepo) SaveLectureDocument(db *gorm.DB, document *entities.LectureDocument) error {
+	if document.ID == 0 {
+		var existing entities.LectureDocument
+		err := db.Where("lecture_id=? AND document_key=?", document.LectureID, document.DocumentKey).First(&existing).Error
+		if err == nil {
+			document.ID = existing.ID
+			document.CreatedAt = existing.CreatedAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("Database: failed to find the lecture document")
+		}
+	}
+	err := db.Save(document).Error
+	if err != nil {
+		return errors.New("Database: failed to save the lecture document")
+	}
+	return nil
+}
+
+func (r *LectureDocumentRepo) FindLectureDocument(db *gorm.DB, lectureID uint, documentKey string) (*entities.LectureDocument, error) {
+	var document entities.LectureDocument
+	err := db.Where("lecture_id=? AND document_key=?", lectureID, documentKey).First(&document).Error
+	if err != nil {
+		return nil, errors.New("Database: lecture document not found")
+	}
+	return &document, nil
+}
+
+func (r *LectureDocumentRepo) FindLectureDocumentsByLectureID(db *gorm.DB, lectureID uint) ([]*entities.LectureDocument, error) {
+	var documents []*entities.LectureDocument
+	err := db.Where("lecture_id=?", lectureID).Order("document_key").Find(&documents).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find lecture documents")
+	}
+	return documents, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type IRuntimeImageRepo interface {
+	CreateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error
+	UpdateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error
+	DeleteRuntimeImage(db *gorm.DB, runtimeImageID uint) error
+	FindRuntimeImageByID(db *gorm.DB, runtimeImageID uint) (*entities.RuntimeImage, error)
+	FindAllRuntimeImages(db *gorm.DB) ([]*entities.RuntimeImage, error)
+
+	SaveClassRuntime(db *gorm.DB, classRuntime *entities.ClassRuntime) error
+	DeleteClassRuntime(db *gorm.DB, classID uint, language string) error
+	DeleteClassRuntimesByRuntimeImageID(db *gorm.DB, runtimeImageID uint) error
+	FindClassRuntime(db *gorm.DB, classID uint, language string) (*entities.ClassRuntime, error)
+	FindClassRuntimesByClassID(db *gorm.DB, classID uint) ([]*entities.ClassRuntime, error)
+}
+
+type RuntimeImageRepo struct {
+}
+
+func NewRuntimeImageRepo() *RuntimeImageRepo {
+	return &RuntimeImageRepo{}
+}
+
+func (r *RuntimeImageRepo) CreateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error {
+	err := db.Create(runtimeImage).Error
+	if err != nil {
+		return errors.New("Database: failed to create the runtime image")
+	}
+	return nil
+}
+
+func (r *RuntimeImageRepo) UpdateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error {
+	err := db.Save(runtimeImage).Error
+	if err != nil {
+		return errors.New("Database: failed to update the runtime image")
+	}
+	return nil
+}
+
+func (r *RuntimeImageRepo) DeleteRuntimeImage(db *gorm.DB, runtimeImageID uint) error {
+	err := db.Delete(&entities.RuntimeImage{}, runtimeImageID).Error
+	if err != nil {
+		return errors.New("Database: failed to delete the runtime image")
+	}
+	return nil
+}
+
+func (r *RuntimeImageRepo) FindRuntimeImageByID(db *gorm.DB, runtimeImageID uint) (*entities.RuntimeImage, error) {
+	var runtimeImage entities.RuntimeImage
+	err := db.Where("ID=?", runtimeImageID).First(&runtimeImage).Error
+	if err != nil {
+		return nil, errors.New("Database: runtime image not found")
+	}
+	return &runtimeImage, nil
+}
+
+func (r *RuntimeImageRepo) FindAllRuntimeImages(db *gorm.DB) ([]*entities.RuntimeImage, error) {
+	var runtimeImages []*entities.RuntimeImage
+	err := db.Order("language, name").Find(&runtimeImages).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find runtime images")
+	}
+	return runtimeImages, nil
+}
+
+// SaveClassRuntime replaces the class's choice for the language.
+func (r *RuntimeImageRepo) SaveClassRuntime(db *gorm.DB, classRuntime *entities.ClassRuntime) error {
+	err := db.Where("class_id=? AND language=?", classRuntime.ClassID, classRuntime.Language).Delete(&entities.ClassRuntime{}).Error
+	if err != nil {
+		return errors.New("Database: failed to replace the class runtime")
+	}
+	err = db.Create(classRuntime).Error
+	if err != nil {
+		return errors.New("Database: failed to save the class runtime")
+	}
+	return nil
+}
+
+func (r *RuntimeImageRepo) DeleteClassRuntime(db *gorm.DB, classID uint, language string) error {
+	err := db.Where("class_id=? AND language=?", classID, language).Delete(&entities.ClassRuntime{}).Error
+	if err != nil {
+		return errors.New("Database: failed to delete the class runtime")
+	}
+	return nil
+}
+
+func (r *RuntimeImageRepo) DeleteClassRuntimesByRuntimeImageID(db *gorm.DB, runtimeImageID uint) error {
+	err := db.Where("runtime_image_id=?", runtimeImageID).Delete(&entities.ClassRuntime{}).Error
+	if err != nil {
+		return errors.New("Database: failed to delete the class runtimes")
+	}
+	return nil
+}
+
+func (r *RuntimeImageRepo) FindClassRuntime(db *gorm.DB, classID uint, language string) (*entities.ClassRuntime, error) {
+	var classRuntime entities.ClassRuntime
+	err := db.Where("class_id=? AND language=?", classID, language).First(&classRuntime).Error
+	if err != nil {
+		return nil, errors.New("Database: class runtime not found")
+	}
+	return &classRuntime, nil
+}
+
+func (r *RuntimeImageRepo) FindClassRuntimesByClassID(db *gorm.DB, classID uint) ([]*entities.ClassRuntime, error) {
+	var classRuntimes []*entities.ClassRuntime
+	err := db.Where("class_id=?", classID).Order("language").Find(&classRuntimes).Error
+	if err != nil {
+		return nil, errors.New("Database: failed to find class runtimes")
+	}
+	return classRuntimes, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/repository"
+	"gorm.io/gorm"
+)
+
+type IExecutionProfileService interface {
+	CreateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error
+	UpdateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error
+	DeleteExecutionProfile(db *gorm.DB, profileID uint) error
+	FindExecutionProfileByID(db *gorm.DB, profileID uint) (*entities.ExecutionProfile, error)
+	FindExecutionProfilesByClassID(db *gorm.DB, classID uint) ([]*entities.ExecutionProfile, error)
+	FindExecutionProfilesForLecture(db *gorm.DB, classID uint, lectureID uint) ([]*entities.ExecutionProfile, error)
+
+	SaveClassPolicy(db *gorm.DB, classPolicy *entities.ClassPolicy) error
+	FindClassPolicy(db *gorm.DB, classID uint) (*entities.ClassPolicy, error)
+}
+
+type ExecutionProfileService struct {
+	ExecutionProfileRepo repository.IExecutionProfileRepo
+}
+
+func NewExecutionProfileService(executionProfileRepo repository.IExecutionProfileRepo) *ExecutionProfileService {
+	return &ExecutionProfileService{ExecutionProfileRepo: executionProfileRepo}
+}
+
+func (e *ExecutionProfileService) CreateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error {
+	return e.ExecutionProfileRepo.CreateExecutionProfile(db, profile)
+}
+
+func (e *ExecutionProfileService) UpdateExecutionProfile(db *gorm.DB, profile *entities.ExecutionProfile) error {
+	return e.ExecutionProfileRepo.UpdateExecutionProfile(db, profile)
+}
+
+func (e *ExecutionProfileService) DeleteExecutionProfile(db *gorm.DB, profileID uint) error {
+	return e.ExecutionProfileRepo.DeleteExecutionProfile(db, profileID)
+}
+
+func (e *ExecutionProfileService) FindExecutionProfileByID(db *gorm.DB, profileID uint) (*entities.ExecutionProfile, error) {
+	return e.ExecutionProfileRepo.FindExecutionProfileByID(db, profileID)
+}
+
+func (e *ExecutionProfileService) FindExecutionProfilesByClassID(db *gorm.DB, classID uint) ([]*entities.ExecutionProfile, error) {
+	return e.ExecutionProfileRepo.FindExecutionProfilesByClassID(db, classID)
+}
+
+func (e *ExecutionProfileService) FindExecutionProfilesForLecture(db *gorm.DB, classID uint, lectureID uint) ([]*entities.ExecutionProfile, error) {
+	return e.ExecutionProfileRepo.FindExecutionProfilesForLecture(db, classID, lectureID)
+}
+
+func (e *ExecutionProfileService) SaveClassPolicy(db *gorm.DB, classPolicy *entities.ClassPolicy) error {
+	return e.ExecutionProfileRepo.SaveClassPolicy(db, classPolicy)
+}
+
+func (e *ExecutionProfileService) FindClassPolicy(db *gorm.DB, classID uint) (*entities.ClassPolicy, error) {
+	return e.ExecutionProfileRepo.FindClassPolicy(db, classID)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/repository"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const maxExecutionQueryLimit = 500
+
+type IExecutionService interface {
+	SaveExecution(db *gorm.DB, execution *entities.Execution, artifacts []*entities.ExecutionArtifact) error
+	FindExecutionByExecutionID(db *gorm.DB, executionID string) (*entities.Execution, error)
+	FindExecutionsByLectureID(db *gorm.DB, lectureID uint, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	FindExecutionsByUserZCode(db *gorm.DB, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	FindExecutionsByLectureIDAndUserZCode(db *gorm.DB, lectureID uint, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error)
+	PurgeExecutionsOlderThan(db *gorm.DB, retention time.Duration) (int64, error)
+	GetLectureExecutionStats(db *gorm.DB, lectureID uint, userZCode string) ([]*entities.ExecutionStats, error)
+
+	FindArtifactsByExecutionID(db *gorm.DB, executionID string) ([]*entities.ExecutionArtifact, error)
+	FindArtifact(db *gorm.DB, executionID string, path string) (*entities.ExecutionArtifact, error)
+}
+
+type ExecutionService struct {
+	ExecutionRepo repository.IExecutionRepo
+}
+
+func NewExecutionService(executionRepo repository.IExecutionRepo) *ExecutionService {
+	return &ExecutionService{ExecutionRepo: executionRepo}
+}
+
+func (e *ExecutionService) SaveExecution(db *gorm.DB, execution *entities.Execution, artifacts []*entities.ExecutionArtifact) error {
+	if execution.ExecutionID == "" {
+		return errors.New("execution id is required")
+	}
+	err := e.ExecutionRepo.CreateExecution(db, execution)
+	if err != nil {
+		return err
+	}
+	for _, artifact := range artifacts {
+		artifact.ExecutionID = execution.ExecutionID
+		err = e.ExecutionRepo.CreateArtifact(db, artifact)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *ExecutionService) FindExecutionByExecutionID(db *gorm.DB, executionID string) (*entities.Execution, error) {
+	return e.ExecutionRepo.FindExecutionByExecutionID(db, executionID)
+}
+
+func (e *ExecutionService) FindExecutionsByLectureID(db *gorm.DB, lectureID uint, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, errors.New("invalid time range")
+	}
+	return e.ExecutionRepo.FindExecutionsByLectureID(db, lectureID, from, to, clampExecutionLimit(limit))
+}
+
+func (e *ExecutionService) FindExecutionsByUserZCode(db *gorm.DB, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, errors.New("invalid time range")
+	}
+	return e.ExecutionRepo.FindExecutionsByUserZCode(db, userZCode, from, to, clampExecutionLimit(limit))
+}
+
+func (e *ExecutionService) FindExecutionsByLectureIDAndUserZCode(db *gorm.DB, lectureID uint, userZCode string, from time.Time, to time.Time, limit int) ([]*entities.Execution, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, errors.New("invalid time range")
+	}
+	return e.ExecutionRepo.FindExecutionsByLectureIDAndUserZCode(db, lectureID, userZCode, from, to, clampExecutionLimit(limit))
+}
+
+func (e *ExecutionService) PurgeExecutionsOlderThan(db *gorm.DB, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, errors.New("retention must be positive")
+	}
+	return e.ExecutionRepo.DeleteExecutionsBefore(db, time.Now().Add(-retention))
+}
+
+func (e *ExecutionService) FindArtifactsByExecutionID(db *gorm.DB, executionID string) ([]*entities.ExecutionArtifact, error) {
+	return e.ExecutionRepo.FindArtifactsByExecutionID(db, executionID)
+}
+
+func (e *ExecutionService) FindArtifact(db *gorm.DB, executionID string, path string) (*entities.ExecutionArtifact, error) {
+	return e.ExecutionRepo.FindArtifact(db, executionID, path)
+}
+
+// GetLectureExecutionStats returns per-user run counts with each user's last error message.
+func (e *ExecutionService) GetLectureExecutionStats(db *gorm.DB, lectureID uint, userZCode string) ([]*entities.ExecutionStats, error) {
+	stats, err := e.ExecutionRepo.SummarizeExecutionsByLectureID(db, lectureID, userZCode)
+	if err != nil {
+		return nil, err
+	}
+	lastErrors, err := e.ExecutionRepo.FindLastErrorsByLectureID(db, lectureID, userZCode)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErrorByUser := make(map[string]*entities.Execution)
+	for _, execution := range lastErrors {
+		lastErrorByUser[execution.UserZCode] = execution
+	}
+	for _, stat := range stats {
+		if execution, exists := lastErrorByUser[stat.UserZCode]; exists {
+			stat.LastError = lastErrorLine(execution)
+			stat.LastErrorAt = execution.CreatedAt
+		}
+	}
+	return stats, nil
+}
+
+// lastErrorLine picks a readable message instead of a generic error such as
+// "exit status 1": the first compiler line, or the last stderr line of a run
+// (e.g. "NameError: name 'x' is not defined").
+func lastErrorLine(execution *entities.Execution) string {
+	if execution.Status == "compile_error" {
+		for _, line := range strings.Split(execution.CompileOutput, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				return line
+			}
+		}
+		return execution.Error
+	}
+
+	index := strings.LastIndex(execution.Output, "--- STDERR ---")
+	if index < 0 {
+		return execution.Error
+	}
+	lines := strings.Split(execution.Output[index+len("--- STDERR ---"):], "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return execution.Error
+}
+
+func clampExecutionLimit(limit int) int {
+	if limit <= 0 || limit > maxExecutionQueryLimit {
+		return maxExecutionQueryLimit
+	}
+	return limit
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/repository"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type IExerciseService interface {
+	CreateExercise(db *gorm.DB, lectureID uint, title string, description string, language string, createdByZCodeID uint64, testCases []*entities.ExerciseTestCase) (*entities.Exercise, error)
+	DeleteExercise(db *gorm.DB, exerciseID uint) error
+	FindExerciseByID(db *gorm.DB, exerciseID uint) (*entities.Exercise, error)
+	FindExercisesByLectureID(db *gorm.DB, lectureID uint) ([]*entities.Exercise, error)
+	FindTestCasesByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseTestCase, error)
+
+	SaveSubmission(db *gorm.DB, submission *entities.ExerciseSubmission) error
+	FindSubmissionsByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseSubmission, error)
+}
+
+type ExerciseService struct {
+	ExerciseRepo repository.IExerciseRepo
+}
+
+func NewExerciseService(exerciseRepo repository.IExerciseRepo) *ExerciseService {
+	return &ExerciseService{ExerciseRepo: exerciseRepo}
+}
+
+func (e *ExerciseService) CreateExercise(db *gorm.DB, lectureID uint, title string, description string, language string, createdByZCodeID uint64, testCases []*entities.ExerciseTestCase) (*entities.Exercise, error) {
+	if len(testCases) == 0 {
+		return nil, errors.New("an exercise needs at least one test case")
+	}
+	exercise := entities.Exercise{LectureID: lectureID, Title: title, Description: description, Language: language, CreatedByZCodeID: createdByZCodeID}
+	err := e.ExerciseRepo.CreateExercise(db, &exercise)
+	if err != nil {
+		return nil, err
+	}
+	for _, testCase := range testCases {
+		testCase.ExerciseID = exercise.ID
+		if testCase.Weight <= 0 {
+			testCase.Weight = 1
+		}
+		err = e.ExerciseRepo.AddTestCase(db, testCase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &exercise, nil
+}
+
+func (e *ExerciseService) DeleteExercise(db *gorm.DB, exerciseID uint) error {
+	return e.ExerciseRepo.DeleteExercise(db, exerciseID)
+}
+
+func (e *ExerciseService) FindExerciseByID(db *gorm.DB, exerciseID uint) (*entities.Exercise, error) {
+	return e.ExerciseRepo.FindExerciseByID(db, exerciseID)
+}
+
+func (e *ExerciseService) FindExercisesByLectureID(db *gorm.DB, lectureID uint) ([]*entities.Exercise, error) {
+	return e.ExerciseRepo.FindExercisesByLectureID(db, lectureID)
+}
+
+func (e *ExerciseService) FindTestCasesByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseTestCase, error) {
+	return e.ExerciseRepo.FindTestCasesByExerciseID(db, exerciseID)
+}
+
+func (e *ExerciseService) SaveSubmission(db *gorm.DB, submission *entities.ExerciseSubmission) error {
+	return e.ExerciseRepo.CreateSubmission(db, submission)
+}
+
+func (e *ExerciseService) FindSubmissionsByExerciseID(db *gorm.DB, exerciseID uint) ([]*entities.ExerciseSubmission, error) {
+	return e.ExerciseRepo.FindSubmissionsByExerciseID(db, exerciseID)
+}
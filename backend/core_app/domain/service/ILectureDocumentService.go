@@ -0,0 +1,33 @@
+package service
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/repository"
+	"gorm.io/gorm"
+)
+
+type ILectureDocumentService interface {
+	SaveLectureDocument(db *gorm.DB, document *entities.LectureDocument) error
+	FindLectureDocument(db *gorm.DB, lectureID uint, documentKey string) (*entities.LectureDocument, error)
+	FindLectureDocumentsByLectureID(db *gorm.DB, lectureID uint) ([]*entities.LectureDocument, error)
+}
+
+type LectureDocumentService struct {
+	LectureDocumentRepo repository.ILectureDocumentRepo
+}
+
+func NewLectureDocumentService(lectureDocumentRepo repository.ILectureDocumentRepo) *LectureDocumentService {
+	return &LectureDocumentService{LectureDocumentRepo: lectureDocumentRepo}
+}
+
+func (l *LectureDocumentService) SaveLectureDocument(db *gorm.DB, document *entities.LectureDocument) error {
+	return l.LectureDocumentRepo.SaveLectureDocument(db, document)
+}
+
+func (l *LectureDocumentService) FindLectureDocument(db *gorm.DB, lectureID uint, documentKey string) (*entities.LectureDocument, error) {
+	return l.LectureDocumentRepo.FindLectureDocument(db, lectureID, documentKey)
+}
+
+func (l *LectureDocumentService) FindLectureDocumentsByLectureID(db *gorm.DB, lectureID uint) ([]*entities.LectureDocument, error) {
+	return l.LectureDocumentRepo.FindLectureDocumentsByLectureID(db, lectureID)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/core_app/domain/repository"
+	"gorm.io/gorm"
+)
+
+type IRuntimeImageService interface {
+	CreateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error
+	UpdateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error
+	DeleteRuntimeImage(db *gorm.DB, runtimeImageID uint) error
+	FindRuntimeImageByID(db *gorm.DB, runtimeImageID uint) (*entities.RuntimeImage, error)
+	FindAllRuntimeImages(db *gorm.DB) ([]*entities.RuntimeImage, error)
+
+	SaveClassRuntime(db *gorm.DB, classRuntime *entities.ClassRuntime) error
+	DeleteClassRuntime(db *gorm.DB, classID uint, language string) error
+	FindClassRuntime(db *gorm.DB, classID uint, language string) (*entities.ClassRuntime, error)
+	FindClassRuntimesByClassID(db *gorm.DB, classID uint) ([]*entities.ClassRuntime, error)
+}
+
+type RuntimeImageService struct {
+	RuntimeImageRepo repository.IRuntimeImageRepo
+}
+
+func NewRuntimeImageService(runtimeImageRepo repository.IRuntimeImageRepo) *RuntimeImageService {
+	return &RuntimeImageService{RuntimeImageRepo: runtimeImageRepo}
+}
+
+func (r *RuntimeImageService) CreateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error {
+	return r.RuntimeImageRepo.CreateRuntimeImage(db, runtimeImage)
+}
+
+func (r *RuntimeImageService) UpdateRuntimeImage(db *gorm.DB, runtimeImage *entities.RuntimeImage) error {
+	return r.RuntimeImageRepo.UpdateRuntimeImage(db, runtimeImage)
+}
+
+// DeleteRuntimeImage also drops the class choices of the image, those classes go
+// back to the default image of the language.
+func (r *RuntimeImageService) DeleteRuntimeImage(db *gorm.DB, runtimeImageID uint) error {
+	if err := r.RuntimeImageRepo.DeleteClassRuntimesByRuntimeImageID(db, runtimeImageID); err != nil {
+		return err
+	}
+	return r.RuntimeImageRepo.DeleteRuntimeImage(db, runtimeImageID)
+}
+
+func (r *RuntimeImageService) FindRuntimeImageByID(db *gorm.DB, runtimeImageID uint) (*entities.RuntimeImage, error) {
+	return r.RuntimeImageRepo.FindRuntimeImageByID(db, runtimeImageID)
+}
+
+func (r *RuntimeImageService) FindAllRuntimeImages(db *gorm.DB) ([]*entities.RuntimeImage, error) {
+	return r.RuntimeImageRepo.FindAllRuntimeImages(db)
+}
+
+func (r *RuntimeImageService) SaveClassRuntime(db *gorm.DB, classRuntime *entities.ClassRuntime) error {
+	return r.RuntimeImageRepo.SaveClassRuntime(db, classRuntime)
+}
+
+func (r *RuntimeImageService) DeleteClassRuntime(db *gorm.DB, classID uint, language string) error {
+	return r.RuntimeImageRepo.DeleteClassRuntime(db, classID, language)
+}
+
+func (r *RuntimeImageService) FindClassRuntime(db *gorm.DB, classID uint, language string) (*entities.ClassRuntime, error) {
+	return r.RuntimeImageRepo.FindClassRuntime(db, classID, language)
+}
+
+func (r *RuntimeImageService) FindClassRuntimesByClassID(db *gorm.DB, classID uint) ([]*entities.ClassRuntime, error) {
+	return r.RuntimeImageRepo.FindClassRuntimesByClassID(db, classID)
+}
@@ -0,0 +1,149 @@
+package access
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TokenZCode reads the caller's ZCode set by AuthMiddleWare.CheckToken and
+// writes the error response itself when it is missing.
+func TokenZCode(c *gin.Context) (uint64, bool) {
+	uZcode, exist := c.Get("Zcode")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Can not find User Zcode",
+		})
+		return 0, false
+	}
+	return uZcode.(uint64), true
+}
+
+func IsLecturer(lectureID uint, zcode uint64) bool {
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(lectureID)
+	if err != nil {
+		return false
+	}
+	return lecture.LecturerZCodeID == zcode
+}
+
+func IsClassManager(classID uint, zcode uint64) bool {
+	class, err := configs.ClassApplications.FindClassByID(classID)
+	if err != nil {
+		return false
+	}
+	return class.ClassManagerZCodeID == zcode
+}
+
+const (
+	RoleTeacher = "teacher"
+	RoleTA      = "ta"
+	RoleStudent = "student"
+
+	// how long before its start time students and TAs may enter a classroom
+	StudentEarlyEntry = 10 * time.Minute
+	TAEarlyEntry      = time.Hour
+)
+
+var (
+	ErrLectureNotFound   = errors.New("lecture not found")
+	ErrNotEnrolled       = errors.New("you are not enrolled in the class of this lecture")
+	ErrLectureNotStarted = errors.New("the lecture has not started yet")
+	ErrLectureEnded      = errors.New("the lecture has ended")
+)
+
+// ClassRole is the caller's role in the class of the lecture: "teacher" for the
+// lecturer and the class manager, otherwise the participant's role in the class.
+// ok is false when the user is not part of the class.
+func ClassRole(lectureID uint, zcode uint64) (string, bool) {
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(lectureID)
+	if err != nil {
+		return "", false
+	}
+	return lectureRole(lecture, zcode)
+}
+
+func lectureRole(lecture *entities.Lecture, zcode uint64) (string, bool) {
+	if lecture.LecturerZCodeID == zcode {
+		return RoleTeacher, true
+	}
+	return ClassRoleByClassID(lecture.ClassID, zcode)
+}
+
+// ClassRoleByClassID is ClassRole for the class itself: "teacher" for the class
+// manager, otherwise the participant's role in the class.
+func ClassRoleByClassID(classID uint, zcode uint64) (string, bool) {
+	if IsClassManager(classID, zcode) {
+		return RoleTeacher, true
+	}
+	participants, err := configs.ClassApplications.FindClassesByParticipantZCodeID(zcode)
+	if err != nil {
+		return "", false
+	}
+	for _, participant := range participants {
+		if participant.ClassID != classID {
+			continue
+		}
+		role := strings.ToLower(strings.TrimSpace(participant.UserRole))
+		switch role {
+		case "":
+			role = RoleStudent
+		case "assistant", "teaching assistant", "teaching_assistant":
+			role = RoleTA
+		}
+		return role, true
+	}
+	return "", false
+}
+
+// ClassroomRole is the role a class role has in the live classroom, which only
+// knows teachers and students: TAs help students like the lecturer does.
+func ClassroomRole(classRole string) string {
+	if classRole == RoleTeacher || classRole == RoleTA {
+		return RoleTeacher
+	}
+	return RoleStudent
+}
+
+// LectureAccess checks that the user may be in the classroom of the lecture at
+// now and returns the lecture and the user's class role. The lecturer and the
+// class manager may enter at any time, TAs and students only between the
+// scheduled start, less their early entry, and the end.
+func LectureAccess(lectureID uint, zcode uint64, now time.Time) (*entities.Lecture, string, error) {
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(lectureID)
+	if err != nil {
+		return nil, "", ErrLectureNotFound
+	}
+	role, ok := lectureRole(lecture, zcode)
+	if !ok {
+		return nil, "", ErrNotEnrolled
+	}
+	if role == RoleTeacher {
+		return lecture, role, nil
+	}
+
+	earlyEntry := StudentEarlyEntry
+	if role == RoleTA {
+		earlyEntry = TAEarlyEntry
+	}
+	if lecture.StartTime != nil && now.Before(lecture.StartTime.Add(-earlyEntry)) {
+		return nil, "", ErrLectureNotStarted
+	}
+	if lecture.EndTime != nil && now.After(*lecture.EndTime) {
+		return nil, "", ErrLectureEnded
+	}
+	return lecture, role, nil
+}
+
+// AccessStatus is the HTTP status for an error of LectureAccess.
+func AccessStatus(err error) int {
+	if errors.Is(err, ErrLectureNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusForbidden
+}
@@ -0,0 +1,79 @@
+// Package bus carries classroom messages between the backend nodes, so the
+// users of one lecture can be connected to different instances.
+package bus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+)
+
+// Audiences of an envelope, which connections of the lecture receive it.
+const (
+	AudienceAll       = "all"       // everyone but Exclude
+	AudienceStudents  = "students"  // every student
+	AudienceTeachers  = "teachers"  // every teacher
+	AudienceUser      = "user"      // UserZCode
+	AudienceExecution = "execution" // UserZCode and the teachers subscribed to their runs
+	AudienceDocument  = "document"  // no connection, the other nodes apply a Yjs update
+	AudienceCancel    = "cancel"    // no connection, the node running Execution stops it
+)
+
+// Envelope is a message for connections of a lecture, wherever they are.
+type Envelope struct {
+	Node      string `json:"node"`
+	LectureID uint   `json:"lecture_id"`
+	Audience  string `json:"audience"`
+	UserZCode string `json:"user_zcode,omitempty"`
+	Exclude   string `json:"exclude,omitempty"`
+	Document  string `json:"document,omitempty"`
+	Execution string `json:"execution,omitempty"`
+	Message   []byte `json:"message"`
+}
+
+// Bus delivers every published envelope to the handler of every node, the
+// publishing node included.
+type Bus interface {
+	Publish(envelope *Envelope) error
+	Subscribe(handler func(envelope *Envelope))
+	Close() error
+}
+
+// NodeID names this process on the bus.
+var NodeID = newNodeID()
+
+func newNodeID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "node"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// LocalBus is the bus of a single instance, envelopes go straight to the
+// handler.
+type LocalBus struct {
+	handler func(envelope *Envelope)
+}
+
+func NewLocalBus() *LocalBus {
+	return &LocalBus{}
+}
+
+func (b *LocalBus) Publish(envelope *Envelope) error {
+	envelope.Node = NodeID
+	if b.handler != nil {
+		b.handler(envelope)
+	}
+	return nil
+}
+
+func (b *LocalBus) Subscribe(handler func(envelope *Envelope)) {
+	b.handler = handler
+}
+
+func (b *LocalBus) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package bus
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/goccy/go-json"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	channelPrefix = "classroom:lecture:"
+	// how long to wait before subscribing again after the connection broke
+	resubscribeDelay = 2 * time.Second
+)
+
+// RedisBus fans envelopes out through Redis pub/sub, one channel per lecture.
+// Every node listens to all lectures and drops what none of its connections
+// needs.
+type RedisBus struct {
+	client *redis.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewRedisBus(client *redis.Client) *RedisBus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RedisBus{client: client, ctx: ctx, cancel: cancel}
+}
+
+func (b *RedisBus) Publish(envelope *Envelope) error {
+	envelope.Node = NodeID
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	channel := channelPrefix + strconv.FormatUint(uint64(envelope.LectureID), 10)
+	return b.client.Publish(b.ctx, channel, payload).Err()
+}
+
+func (b *RedisBus) Subscribe(handler func(envelope *Envelope)) {
+	go b.listen(handler)
+}
+
+func (b *RedisBus) Close() error {
+	b.cancel()
+	return nil
+}
+
+func (b *RedisBus) listen(handler func(envelope *Envelope)) {
+	for b.ctx.Err() == nil {
+		pubsub := b.client.PSubscribe(b.ctx, channelPrefix+"*")
+		if _, err := pubsub.Receive(b.ctx); err != nil {
+			log.Printf("classroom bus: failed to subscribe: %v", err)
+			pubsub.Close()
+			time.Sleep(resubscribeDelay)
+			continue
+		}
+		log.Printf("classroom bus: node %s listening on redis", NodeID)
+		go func() {
+			<-b.ctx.Done()
+			pubsub.Close()
+		}()
+
+		// the channel only closes with the subscription, go-redis reconnects it
+		for message := range pubsub.Channel() {
+			var envelope Envelope
+			if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+				log.Printf("classroom bus: invalid envelope on %s: %v", message.Channel, err)
+				continue
+			}
+			handler(&envelope)
+		}
+	}
+}
@@ -4,49 +4,44 @@ import (
 	"MScProject/online_classroom/types"
 	"fmt"
 	"log"
-	"sync"
 	"time"
 )
 
 type ClassroomManager struct {
-	classrooms map[uint]*types.Classroom
-	mutex      sync.RWMutex
+	store Store
 }
 
 var GlobalClassroomManager = &ClassroomManager{
-	classrooms: make(map[uint]*types.Classroom),
+	store: newMemoryStore(),
 }
 
-func (cm *ClassroomManager) GetOrCreateClassroom(lectureID uint, teacherZCode string) *types.Classroom {
-	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
+func errClassroomNotExist(lectureID uint) error {
+	return fmt.Errorf("classroom %d not exist", lectureID)
+}
+
+// SetStore moves presence and chat to store, before the server accepts
+// connections.
+func (cm *ClassroomManager) SetStore(store Store) {
+	cm.store = store
+}
 
-	if classroom, exists := cm.classrooms[lectureID]; exists {
-		return classroom
+func (cm *ClassroomManager) GetOrCreateClassroom(lectureID uint, teacherZCode string) error {
+	if _, _, exists := cm.store.Classroom(lectureID); exists {
+		return nil
+	}
+	if err := cm.store.CreateClassroom(lectureID, teacherZCode); err != nil {
+		return err
 	}
-	classroom := types.NewClassroom(lectureID, teacherZCode)
-	cm.classrooms[lectureID] = classroom
 
 	log.Printf("Create new classroom: ID=%d, Teacher=%s", lectureID, teacherZCode)
-	return classroom
+	return nil
 }
 
-func (cm *ClassroomManager) GetClassroom(lectureID uint) *types.Classroom {
-	cm.mutex.RLock()
-	defer cm.mutex.RUnlock()
-
-	if classroom, exists := cm.classrooms[lectureID]; exists {
-		return classroom
-	}
-	return nil
+func (cm *ClassroomManager) GetUser(lectureID uint, userZCode string) *types.User {
+	return cm.store.GetUser(lectureID, userZCode)
 }
 
 func (cm *ClassroomManager) AddUser(lectureID uint, userZCode, userName, userRole string) error {
-	classroom := cm.GetClassroom(lectureID)
-	if classroom == nil {
-		return fmt.Errorf("classroom %d not exist", lectureID)
-	}
-
 	user := &types.User{
 		ZCode:    userZCode,
 		Name:     userName,
@@ -54,22 +49,22 @@ func (cm *ClassroomManager) AddUser(lectureID uint, userZCode, userName, userRol
 		JoinedAt: time.Now(),
 	}
 
-	classroom.AddUser(user)
+	if err := cm.store.AddUser(lectureID, user); err != nil {
+		return err
+	}
 	log.Printf("user enter classroom: %s (%s) -> classroom %d", userName, userRole, lectureID)
 
 	return nil
 }
 
 func (cm *ClassroomManager) RemoveUser(lectureID uint, userZCode string) error {
-	classroom := cm.GetClassroom(lectureID)
-	if classroom == nil {
-		return fmt.Errorf("classroom %d not exist", lectureID)
+	remaining, err := cm.store.RemoveUser(lectureID, userZCode)
+	if err != nil {
+		return err
 	}
-
-	classroom.RemoveUser(userZCode)
 	log.Printf("user leave the classroom: %s <- classroom %d", userZCode, lectureID)
 
-	if classroom.GetUserCount() == 0 {
+	if remaining == 0 {
 		cm.deleteClassroom(lectureID)
 	}
 
@@ -77,20 +72,22 @@ func (cm *ClassroomManager) RemoveUser(lectureID uint, userZCode string) error {
 }
 
 func (cm *ClassroomManager) AddChatMessage(lectureID uint, senderID, content string) (*types.ChatMessage, error) {
-	classroom := cm.GetClassroom(lectureID)
-	if classroom == nil {
-		return nil, fmt.Errorf("classroom %d not exist", lectureID)
+	if _, _, exists := cm.store.Classroom(lectureID); !exists {
+		return nil, errClassroomNotExist(lectureID)
 	}
 
-	message := classroom.AddChatMessage(senderID, content)
+	message := types.NewChatMessage(senderID, content)
+	if err := cm.store.AddChatMessage(lectureID, message); err != nil {
+		return nil, err
+	}
 	log.Printf("chat message add: %s -> classroom %d", senderID, lectureID)
 
 	return message, nil
 }
 
 func (cm *ClassroomManager) GetClassroomState(lectureID uint) map[string]interface{} {
-	classroom := cm.GetClassroom(lectureID)
-	if classroom == nil {
+	teacherZCode, createdAt, exists := cm.store.Classroom(lectureID)
+	if !exists {
 		return map[string]interface{}{
 			"online_users":  []interface{}{},
 			"chat_messages": []interface{}{},
@@ -98,45 +95,45 @@ func (cm *ClassroomManager) GetClassroomState(lectureID uint) map[string]interfa
 		}
 	}
 
+	users := cm.store.GetUsers(lectureID)
 	return map[string]interface{}{
-		"lecture_id":    classroom.LectureID,
-		"teacher_zcode": classroom.TeacherZCode,
-		"online_users":  classroom.GetUsers(),
-		"chat_messages": classroom.GetChatMessages(),
-		"online_count":  classroom.GetUserCount(),
-		"created_at":    classroom.CreatedAt,
+		"lecture_id":    lectureID,
+		"teacher_zcode": teacherZCode,
+		"online_users":  users,
+		"chat_messages": cm.store.GetChatMessages(lectureID),
+		"online_count":  len(users),
+		"created_at":    createdAt,
 	}
 }
 
 func (cm *ClassroomManager) deleteClassroom(lectureID uint) {
-	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
-
-	delete(cm.classrooms, lectureID)
+	cm.store.DeleteClassroom(lectureID)
 	log.Printf("classroom has been deleted: ID=%d", lectureID)
 }
 
 func (cm *ClassroomManager) GetStats() map[string]interface{} {
-	cm.mutex.RLock()
-	defer cm.mutex.RUnlock()
-
 	totalUsers := 0
 	classroomStats := make([]map[string]interface{}, 0)
 
-	for lectureID, classroom := range cm.classrooms {
-		userCount := classroom.GetUserCount()
+	lectures := cm.store.Lectures()
+	for _, lectureID := range lectures {
+		teacherZCode, createdAt, exists := cm.store.Classroom(lectureID)
+		if !exists {
+			continue
+		}
+		userCount := len(cm.store.GetUsers(lectureID))
 		totalUsers += userCount
 
 		classroomStats = append(classroomStats, map[string]interface{}{
 			"lecture_id":    lectureID,
-			"teacher_zcode": classroom.TeacherZCode,
+			"teacher_zcode": teacherZCode,
 			"user_count":    userCount,
-			"created_at":    classroom.CreatedAt,
+			"created_at":    createdAt,
 		})
 	}
 
 	return map[string]interface{}{
-		"total_classrooms": len(cm.classrooms),
+		"total_classrooms": len(classroomStats),
 		"total_users":      totalUsers,
 		"classrooms":       classroomStats,
 	}
@@ -0,0 +1,234 @@
+package classroom
+
+import (
+	"MScProject/online_classroom/types"
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/goccy/go-json"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	// a node that stops refreshing its key is gone, its users are no longer online
+	nodeTTL       = 30 * time.Second
+	nodeHeartbeat = 10 * time.Second
+	// classrooms nobody closed, e.g. when every node of their users crashed
+	classroomKeyTTL = 24 * time.Hour
+)
+
+// removeUserScript drops the user only if the connection is on this node; after
+// a reconnect to another node the old node must not remove the new presence.
+var removeUserScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) == ARGV[2] then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// RedisStore keeps presence and chat in Redis so every node sees the users
+// connected to the others. Each user is recorded with the node holding the
+// connection, users of nodes that stopped their heartbeat are dropped.
+type RedisStore struct {
+	client *redis.Client
+	node   string
+}
+
+func NewRedisStore(client *redis.Client, node string) *RedisStore {
+	s := &RedisStore{client: client, node: node}
+	s.beat()
+	go s.heartbeat()
+	return s
+}
+
+func lectureKey(lectureID uint, suffix string) string {
+	return "classroom:" + strconv.FormatUint(uint64(lectureID), 10) + ":" + suffix
+}
+
+func nodeKey(node string) string {
+	return "classroom:node:" + node
+}
+
+const lecturesKey = "classroom:lectures"
+
+func (s *RedisStore) beat() {
+	if err := s.client.Set(context.Background(), nodeKey(s.node), 1, nodeTTL).Err(); err != nil {
+		log.Printf("classroom store: heartbeat failed: %v", err)
+	}
+}
+
+func (s *RedisStore) heartbeat() {
+	ticker := time.NewTicker(nodeHeartbeat)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.beat()
+	}
+}
+
+// touch keeps the keys of a classroom in use alive.
+func (s *RedisStore) touch(ctx context.Context, pipe redis.Pipeliner, lectureID uint) {
+	for _, suffix := range []string{"meta", "users", "nodes", "chat"} {
+		pipe.Expire(ctx, lectureKey(lectureID, suffix), classroomKeyTTL)
+	}
+}
+
+func (s *RedisStore) CreateClassroom(lectureID uint, teacherZCode string) error {
+	ctx := context.Background()
+	meta := lectureKey(lectureID, "meta")
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSetNX(ctx, meta, "teacher_zcode", teacherZCode)
+		pipe.HSetNX(ctx, meta, "created_at", time.Now().Format(time.RFC3339Nano))
+		pipe.SAdd(ctx, lecturesKey, lectureID)
+		s.touch(ctx, pipe, lectureID)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Classroom(lectureID uint) (string, time.Time, bool) {
+	meta, err := s.client.HGetAll(context.Background(), lectureKey(lectureID, "meta")).Result()
+	if err != nil || len(meta) == 0 {
+		return "", time.Time{}, false
+	}
+	createdAt, _ := time.Parse(time.RFC3339Nano, meta["created_at"])
+	return meta["teacher_zcode"], createdAt, true
+}
+
+func (s *RedisStore) DeleteClassroom(lectureID uint) {
+	ctx := context.Background()
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, lectureKey(lectureID, "meta"), lectureKey(lectureID, "users"), lectureKey(lectureID, "nodes"), lectureKey(lectureID, "chat"))
+		pipe.SRem(ctx, lecturesKey, lectureID)
+		return nil
+	})
+	if err != nil {
+		log.Printf("classroom store: failed to delete classroom %d: %v", lectureID, err)
+	}
+}
+
+func (s *RedisStore) Lectures() []uint {
+	ctx := context.Background()
+	members, err := s.client.SMembers(ctx, lecturesKey).Result()
+	if err != nil {
+		return []uint{}
+	}
+	lectures := make([]uint, 0, len(members))
+	for _, member := range members {
+		lectureID, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			continue
+		}
+		// the keys of a forgotten classroom expired, drop it from the set too
+		if exists, _ := s.client.Exists(ctx, lectureKey(uint(lectureID), "meta")).Result(); exists == 0 {
+			s.client.SRem(ctx, lecturesKey, member)
+			continue
+		}
+		lectures = append(lectures, uint(lectureID))
+	}
+	return lectures
+}
+
+func (s *RedisStore) AddUser(lectureID uint, user *types.User) error {
+	if _, _, ok := s.Classroom(lectureID); !ok {
+		return errClassroomNotExist(lectureID)
+	}
+	payload, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, lectureKey(lectureID, "users"), user.ZCode, payload)
+		pipe.HSet(ctx, lectureKey(lectureID, "nodes"), user.ZCode, s.node)
+		s.touch(ctx, pipe, lectureID)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) RemoveUser(lectureID uint, userZCode string) (int, error) {
+	keys := []string{lectureKey(lectureID, "users"), lectureKey(lectureID, "nodes")}
+	if err := removeUserScript.Run(context.Background(), s.client, keys, userZCode, s.node).Err(); err != nil {
+		return 0, err
+	}
+	return len(s.GetUsers(lectureID)), nil
+}
+
+func (s *RedisStore) GetUser(lectureID uint, userZCode string) *types.User {
+	payload, err := s.client.HGet(context.Background(), lectureKey(lectureID, "users"), userZCode).Result()
+	if err != nil {
+		return nil
+	}
+	var user types.User
+	if err := json.Unmarshal([]byte(payload), &user); err != nil {
+		return nil
+	}
+	return &user
+}
+
+// GetUsers returns the users whose node is alive and forgets the others.
+func (s *RedisStore) GetUsers(lectureID uint) []*types.User {
+	ctx := context.Background()
+	users := make([]*types.User, 0)
+	payloads, err := s.client.HGetAll(ctx, lectureKey(lectureID, "users")).Result()
+	if err != nil || len(payloads) == 0 {
+		return users
+	}
+	nodes, err := s.client.HGetAll(ctx, lectureKey(lectureID, "nodes")).Result()
+	if err != nil {
+		return users
+	}
+
+	alive := make(map[string]bool)
+	for zcode, payload := range payloads {
+		node := nodes[zcode]
+		if _, checked := alive[node]; !checked {
+			exists, err := s.client.Exists(ctx, nodeKey(node)).Result()
+			// when Redis does not answer, nobody is taken offline
+			alive[node] = err != nil || exists > 0
+		}
+		if !alive[node] {
+			removeUserScript.Run(ctx, s.client, []string{lectureKey(lectureID, "users"), lectureKey(lectureID, "nodes")}, zcode, node)
+			continue
+		}
+		var user types.User
+		if err := json.Unmarshal([]byte(payload), &user); err == nil {
+			users = append(users, &user)
+		}
+	}
+	return users
+}
+
+func (s *RedisStore) AddChatMessage(lectureID uint, message *types.ChatMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	chat := lectureKey(lectureID, "chat")
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, chat, payload)
+		pipe.LTrim(ctx, chat, -types.MaxChatMessages, -1)
+		s.touch(ctx, pipe, lectureID)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) GetChatMessages(lectureID uint) []*types.ChatMessage {
+	messages := make([]*types.ChatMessage, 0)
+	payloads, err := s.client.LRange(context.Background(), lectureKey(lectureID, "chat"), 0, -1).Result()
+	if err != nil {
+		return messages
+	}
+	for _, payload := range payloads {
+		var message types.ChatMessage
+		if err := json.Unmarshal([]byte(payload), &message); err == nil {
+			messages = append(messages, &message)
+		}
+	}
+	return messages
+}
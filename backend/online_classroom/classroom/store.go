@@ -0,0 +1,131 @@
+package classroom
+
+import (
+	"MScProject/online_classroom/types"
+	"sync"
+	"time"
+)
+
+// Store holds the presence and chat of the open classrooms. The memory store
+// serves a single instance, RedisStore shares them between instances.
+type Store interface {
+	// CreateClassroom opens the classroom of the lecture unless it is open.
+	CreateClassroom(lectureID uint, teacherZCode string) error
+	// Classroom returns the teacher and opening time of an open classroom.
+	Classroom(lectureID uint) (teacherZCode string, createdAt time.Time, ok bool)
+	DeleteClassroom(lectureID uint)
+	// Lectures lists the lectures with an open classroom.
+	Lectures() []uint
+
+	AddUser(lectureID uint, user *types.User) error
+	// RemoveUser returns how many users are left in the classroom.
+	RemoveUser(lectureID uint, userZCode string) (int, error)
+	GetUser(lectureID uint, userZCode string) *types.User
+	GetUsers(lectureID uint) []*types.User
+
+	AddChatMessage(lectureID uint, message *types.ChatMessage) error
+	GetChatMessages(lectureID uint) []*types.ChatMessage
+}
+
+type memoryStore struct {
+	classrooms map[uint]*types.Classroom
+	mutex      sync.RWMutex
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		classrooms: make(map[uint]*types.Classroom),
+	}
+}
+
+func (s *memoryStore) get(lectureID uint) *types.Classroom {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.classrooms[lectureID]
+}
+
+func (s *memoryStore) CreateClassroom(lectureID uint, teacherZCode string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.classrooms[lectureID]; !exists {
+		s.classrooms[lectureID] = types.NewClassroom(lectureID, teacherZCode)
+	}
+	return nil
+}
+
+func (s *memoryStore) Classroom(lectureID uint) (string, time.Time, bool) {
+	classroom := s.get(lectureID)
+	if classroom == nil {
+		return "", time.Time{}, false
+	}
+	return classroom.TeacherZCode, classroom.CreatedAt, true
+}
+
+func (s *memoryStore) DeleteClassroom(lectureID uint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.classrooms, lectureID)
+}
+
+func (s *memoryStore) Lectures() []uint {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	lectures := make([]uint, 0, len(s.classrooms))
+	for lectureID := range s.classrooms {
+		lectures = append(lectures, lectureID)
+	}
+	return lectures
+}
+
+func (s *memoryStore) AddUser(lectureID uint, user *types.User) error {
+	classroom := s.get(lectureID)
+	if classroom == nil {
+		return errClassroomNotExist(lectureID)
+	}
+	classroom.AddUser(user)
+	return nil
+}
+
+func (s *memoryStore) RemoveUser(lectureID uint, userZCode string) (int, error) {
+	classroom := s.get(lectureID)
+	if classroom == nil {
+		return 0, errClassroomNotExist(lectureID)
+	}
+	classroom.RemoveUser(userZCode)
+	return classroom.GetUserCount(), nil
+}
+
+func (s *memoryStore) GetUser(lectureID uint, userZCode string) *types.User {
+	classroom := s.get(lectureID)
+	if classroom == nil {
+		return nil
+	}
+	return classroom.GetUser(userZCode)
+}
+
+func (s *memoryStore) GetUsers(lectureID uint) []*types.User {
+	classroom := s.get(lectureID)
+	if classroom == nil {
+		return []*types.User{}
+	}
+	return classroom.GetUsers()
+}
+
+func (s *memoryStore) AddChatMessage(lectureID uint, message *types.ChatMessage) error {
+	classroom := s.get(lectureID)
+	if classroom == nil {
+		return errClassroomNotExist(lectureID)
+	}
+	classroom.AppendChatMessage(message)
+	return nil
+}
+
+func (s *memoryStore) GetChatMessages(lectureID uint) []*types.ChatMessage {
+	classroom := s.get(lectureID)
+	if classroom == nil {
+		return []*types.ChatMessage{}
+	}
+	return classroom.GetChatMessages()
+}
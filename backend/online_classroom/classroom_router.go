@@ -4,9 +4,17 @@ import (
 	"MScProject/configs"
 	"MScProject/core_app/webInterface/routers"
 	"MScProject/online_classroom/execution"
+	"MScProject/online_classroom/grading"
+	"MScProject/online_classroom/similarity"
+	"MScProject/online_classroom/websocket"
 )
 
 func ClassroomRouter() {
+	setUpClassroomBus()
+	execution.GlobalExecutionManager.SetStreamer(websocket.GlobalWSManager)
+	execution.GlobalReplManager.SetStreamer(websocket.GlobalWSManager)
+	execution.CheckExecutionBackend()
+
 	routers.R.GET("/ws/classroom/:lecture_id", WebSocketUpgradeHandler)
 	api := routers.R.Group("/api")
 	api.Use(configs.AuthMiddleWares.CheckToken())
@@ -20,6 +28,36 @@ func ClassroomRouter() {
 		{
 			execut.POST("/execute", execution.ExecuteCodeHandler)
 			execut.GET("/result/:id", execution.GetExecutionResultHandler)
+			execut.GET("/result/:id/artifact", execution.DownloadArtifactHandler)
+			execut.POST("/cancel/:id", execution.CancelExecutionHandler)
+			execut.GET("/history", execution.ExecutionHistoryHandler)
+			execut.GET("/lecture/:lecture_id/stats", execution.LectureExecutionStatsHandler)
+			execut.GET("/languages", execution.ListLanguagesHandler)
+			execut.GET("/policies", execution.ListPoliciesHandler)
+			execut.POST("/policy/class/:class_id", execution.SetClassPolicyHandler)
+			execut.GET("/profiles", execution.ListExecutionProfilesHandler)
+			execut.POST("/profile/create", execution.CreateExecutionProfileHandler)
+			execut.POST("/profile/update/:id", execution.UpdateExecutionProfileHandler)
+			execut.POST("/profile/delete/:id", execution.DeleteExecutionProfileHandler)
+			execut.GET("/profile/resolve", execution.ResolveExecutionProfileHandler)
+			execut.GET("/runtimes", execution.ListRuntimeImagesHandler)
+			execut.POST("/runtime/create", configs.AuthMiddleWares.CheckPermissions(), execution.CreateRuntimeImageHandler)
+			execut.POST("/runtime/update", configs.AuthMiddleWares.CheckPermissions(), execution.UpdateRuntimeImageHandler)
+			execut.POST("/runtime/delete", configs.AuthMiddleWares.CheckPermissions(), execution.DeleteRuntimeImageHandler)
+			execut.GET("/runtime/class/:class_id", execution.ListClassRuntimesHandler)
+			execut.POST("/runtime/class/:class_id", execution.SetClassRuntimeHandler)
+			execut.GET("/packages", execution.ListPackagesHandler)
+		}
+		grade := api.Group("/grading")
+		{
+			grade.POST("/exercise/create", grading.CreateExerciseHandler)
+			grade.GET("/lecture/:lecture_id/exercises", grading.ListExercisesHandler)
+			grade.POST("/submit", grading.SubmitHandler)
+			grade.GET("/exercise/:id/summary", grading.ExerciseSummaryHandler)
+		}
+		similar := api.Group("/similarity")
+		{
+			similar.GET("/lecture/:lecture_id", similarity.LectureReportHandler)
 		}
 
 		api.GET("/stats", GetStatsHandler)
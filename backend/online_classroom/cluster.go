@@ -0,0 +1,31 @@
+package online_classroom
+
+import (
+	"MScProject/core_app/infrastructure"
+	"MScProject/online_classroom/bus"
+	"MScProject/online_classroom/classroom"
+	"MScProject/online_classroom/execution"
+	"MScProject/online_classroom/websocket"
+	"log"
+	"os"
+)
+
+const (
+	ClassroomBusLocal = "local" // one instance, messages and presence stay in memory
+	ClassroomBusRedis = "redis" // several instances behind a load balancer share Redis
+)
+
+// setUpClassroomBus connects the classrooms of this instance to the others as
+// CLASSROOM_BUS says.
+func setUpClassroomBus() {
+	switch backend := os.Getenv("CLASSROOM_BUS"); backend {
+	case ClassroomBusRedis:
+		websocket.GlobalWSManager.SetBus(bus.NewRedisBus(infrastructure.RedisClient))
+		classroom.GlobalClassroomManager.SetStore(classroom.NewRedisStore(infrastructure.RedisClient, bus.NodeID))
+		execution.GlobalExecutionManager.SetRegistry(execution.NewRedisRunRegistry(infrastructure.RedisClient, bus.NodeID))
+		log.Printf("classrooms shared through redis as node %s", bus.NodeID)
+	case "", ClassroomBusLocal:
+	default:
+		log.Printf("WARNING: unknown CLASSROOM_BUS %q, using %s", backend, ClassroomBusLocal)
+	}
+}
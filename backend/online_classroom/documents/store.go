@@ -0,0 +1,306 @@
+package documents
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"MScProject/online_classroom/yjs"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxUpdateBytes is the largest update accepted; the editor sends its whole
+	// state with every change, a code file stays far below this.
+	MaxUpdateBytes = 1 << 20
+
+	// a log this long is merged into the snapshot right away
+	compactLogUpdates = 200
+	compactLogBytes   = 8 << 20
+	// how often logs are compacted, changed documents saved and ended lectures
+	// finalized
+	compactInterval = 30 * time.Second
+	// a document nobody touched for this long is saved and dropped from memory,
+	// e.g. one kept in step for another node after the users here left
+	idleTimeout = 15 * time.Minute
+)
+
+var (
+	ErrUpdateTooLarge = errors.New("yjs update is too large")
+	ErrInvalidUpdate  = errors.New("invalid yjs update")
+)
+
+// document is the server copy of one Yjs document: the merged state at the last
+// compaction plus the updates received since.
+type document struct {
+	lectureID   uint
+	key         string
+	loaded      bool
+	closed      bool
+	saved       *entities.LectureDocument
+	snapshot    []byte
+	log         [][]byte
+	logBytes    int
+	updateCount uint64
+	dirty       bool
+	lastUsed    time.Time
+	mutex       sync.Mutex
+}
+
+// DocumentStore keeps the collaborative documents of running lectures. Every
+// update is applied here before it is relayed, so the server can answer sync
+// requests when the peer owning a document is gone.
+type DocumentStore struct {
+	documents map[uint]map[string]*document
+	mutex     sync.Mutex
+}
+
+var GlobalDocumentStore = NewDocumentStore()
+
+func NewDocumentStore() *DocumentStore {
+	ds := &DocumentStore{
+		documents: make(map[uint]map[string]*document),
+	}
+	go ds.compactor()
+	return ds
+}
+
+// Apply validates update and appends it to the log of the document.
+func (ds *DocumentStore) Apply(lectureID uint, key string, update []byte) error {
+	if len(update) > MaxUpdateBytes {
+		return ErrUpdateTooLarge
+	}
+	if _, err := yjs.DecodeUpdate(update); err != nil {
+		return ErrInvalidUpdate
+	}
+
+	doc := ds.lockDocument(lectureID, key)
+	defer doc.mutex.Unlock()
+
+	doc.log = append(doc.log, update)
+	doc.logBytes += len(update)
+	doc.updateCount++
+	doc.dirty = true
+	if len(doc.log) >= compactLogUpdates || doc.logBytes >= compactLogBytes {
+		doc.compact()
+	}
+	return nil
+}
+
+// State returns the whole document as one update, false if the server has
+// nothing of it.
+func (ds *DocumentStore) State(lectureID uint, key string) ([]byte, bool) {
+	doc := ds.lockDocument(lectureID, key)
+	defer doc.mutex.Unlock()
+
+	doc.compact()
+	return doc.snapshot, len(doc.snapshot) > 0
+}
+
+// Sync returns what a peer with stateVector is missing of the document. Without
+// a state vector the whole document is returned.
+func (ds *DocumentStore) Sync(lectureID uint, key string, stateVector []byte) ([]byte, bool, error) {
+	state, ok := ds.State(lectureID, key)
+	if !ok || len(stateVector) == 0 {
+		return state, ok, nil
+	}
+	clocks, err := yjs.DecodeStateVector(stateVector)
+	if err != nil {
+		return nil, false, errors.New("invalid state vector")
+	}
+	diff, err := yjs.DiffUpdate(state, clocks)
+	if err != nil {
+		return nil, false, err
+	}
+	return diff, true, nil
+}
+
+// CloseLecture saves the documents of the lecture and drops them from memory,
+// called when its last connection is gone.
+func (ds *DocumentStore) CloseLecture(lectureID uint) {
+	ds.closeLecture(lectureID, nil)
+}
+
+// FinalizeLecture saves the final state of the documents of an ended lecture.
+func (ds *DocumentStore) FinalizeLecture(lectureID uint) {
+	now := time.Now()
+	ds.closeLecture(lectureID, &now)
+	log.Printf("documents of lecture %d finalized", lectureID)
+}
+
+// lockDocument returns the locked, loaded document, creating it on first use.
+func (ds *DocumentStore) lockDocument(lectureID uint, key string) *document {
+	for {
+		ds.mutex.Lock()
+		if ds.documents[lectureID] == nil {
+			ds.documents[lectureID] = make(map[string]*document)
+		}
+		doc, exists := ds.documents[lectureID][key]
+		if !exists {
+			doc = &document{lectureID: lectureID, key: key}
+			ds.documents[lectureID][key] = doc
+		}
+		ds.mutex.Unlock()
+
+		doc.mutex.Lock()
+		// closed while we waited, the next one loads what it saved
+		if doc.closed {
+			doc.mutex.Unlock()
+			continue
+		}
+		doc.load()
+		doc.lastUsed = time.Now()
+		return doc
+	}
+}
+
+func (ds *DocumentStore) closeLecture(lectureID uint, finalizedAt *time.Time) {
+	for _, doc := range ds.snapshotAll()[lectureID] {
+		doc.mutex.Lock()
+		ds.closeDocument(doc, finalizedAt)
+		doc.mutex.Unlock()
+	}
+}
+
+// closeDocument saves a locked document and removes it while still holding its
+// lock, so no update lands on a document that is already saved.
+func (ds *DocumentStore) closeDocument(doc *document, finalizedAt *time.Time) {
+	if finalizedAt != nil {
+		doc.dirty = true
+	}
+	doc.persist(finalizedAt)
+	doc.closed = true
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	if ds.documents[doc.lectureID][doc.key] == doc {
+		delete(ds.documents[doc.lectureID], doc.key)
+		if len(ds.documents[doc.lectureID]) == 0 {
+			delete(ds.documents, doc.lectureID)
+		}
+	}
+}
+
+func (ds *DocumentStore) snapshotAll() map[uint][]*document {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	result := make(map[uint][]*document, len(ds.documents))
+	for lectureID, docs := range ds.documents {
+		for _, doc := range docs {
+			result[lectureID] = append(result[lectureID], doc)
+		}
+	}
+	return result
+}
+
+// compactor merges the logs, saves what changed since the last round and
+// finalizes the documents of lectures whose end time has passed.
+func (ds *DocumentStore) compactor() {
+	ticker := time.NewTicker(compactInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		for lectureID, docs := range ds.snapshotAll() {
+			if lectureEnded(lectureID, now) {
+				ds.FinalizeLecture(lectureID)
+				continue
+			}
+			for _, doc := range docs {
+				doc.mutex.Lock()
+				if !doc.closed && now.Sub(doc.lastUsed) > idleTimeout {
+					ds.closeDocument(doc, nil)
+				} else {
+					doc.persist(nil)
+				}
+				doc.mutex.Unlock()
+			}
+		}
+	}
+}
+
+func lectureEnded(lectureID uint, now time.Time) bool {
+	if configs.ClassApplications == nil {
+		return false
+	}
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(lectureID)
+	if err != nil {
+		return false
+	}
+	return lecture.EndTime != nil && now.After(*lecture.EndTime)
+}
+
+// load reads the saved state once, a document written before a restart or by
+// an earlier session of the lecture continues from there.
+func (d *document) load() {
+	if d.loaded {
+		return
+	}
+	d.loaded = true
+	if configs.LectureDocumentApplications == nil {
+		return
+	}
+	saved, err := configs.LectureDocumentApplications.FindLectureDocument(d.lectureID, d.key)
+	if err != nil {
+		return
+	}
+	d.saved = saved
+	d.snapshot = saved.State
+	d.updateCount = saved.UpdateCount
+}
+
+// compact merges the log into the snapshot.
+func (d *document) compact() {
+	if len(d.log) == 0 {
+		return
+	}
+	updates := d.log
+	if len(d.snapshot) > 0 {
+		updates = append([][]byte{d.snapshot}, d.log...)
+	}
+	merged, err := yjs.MergeUpdates(updates...)
+	if err != nil {
+		log.Printf("failed to compact document %s of lecture %d: %v", d.key, d.lectureID, err)
+		return
+	}
+	d.snapshot = merged
+	d.log = nil
+	d.logBytes = 0
+}
+
+// persist saves the document if it changed, with finalizedAt once the lecture
+// has ended.
+func (d *document) persist(finalizedAt *time.Time) {
+	d.compact()
+	if !d.dirty || len(d.log) > 0 {
+		return
+	}
+	content, err := yjs.Text(d.snapshot, d.key)
+	if err != nil {
+		log.Printf("failed to read the text of document %s of lecture %d: %v", d.key, d.lectureID, err)
+	}
+
+	now := time.Now()
+	saved := d.saved
+	if saved == nil {
+		saved = &entities.LectureDocument{LectureID: d.lectureID, DocumentKey: d.key, BaseEntity: entities.BaseEntity{CreatedAt: &now}}
+	}
+	saved.State = d.snapshot
+	saved.Content = content
+	saved.UpdateCount = d.updateCount
+	saved.UpdatedAt = &now
+	if finalizedAt != nil {
+		saved.FinalizedAt = finalizedAt
+	}
+	if configs.LectureDocumentApplications == nil {
+		return
+	}
+	if err := configs.LectureDocumentApplications.SaveLectureDocument(saved); err != nil {
+		log.Printf("failed to save document %s of lecture %d: %v", d.key, d.lectureID, err)
+		return
+	}
+	d.saved = saved
+	d.dirty = false
+}
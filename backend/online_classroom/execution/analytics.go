@@ -0,0 +1,43 @@
+package execution
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"strconv"
+)
+
+type StudentExecutionStats struct {
+	*entities.ExecutionStats
+	ErrorRatio float64 `json:"error_ratio"` // failed runs / (failed + successful runs)
+}
+
+// LectureExecutionStats summarises the students' runs in a lecture, leaving out the
+// lecturer's own. An empty userZCode returns every student who has run code.
+func LectureExecutionStats(lectureID uint, userZCode string) ([]*StudentExecutionStats, error) {
+	if configs.ExecutionApplications == nil {
+		return nil, errors.New("execution history is not available")
+	}
+	stats, err := configs.ExecutionApplications.GetLectureExecutionStats(lectureID, userZCode)
+	if err != nil {
+		return nil, err
+	}
+
+	lecturerZCode := ""
+	if lecture, err := configs.ClassApplications.FindLectureByLectureID(lectureID); err == nil {
+		lecturerZCode = strconv.FormatUint(lecture.LecturerZCodeID, 10)
+	}
+
+	studentStats := make([]*StudentExecutionStats, 0, len(stats))
+	for _, stat := range stats {
+		if stat.UserZCode == lecturerZCode {
+			continue
+		}
+		studentStat := &StudentExecutionStats{ExecutionStats: stat}
+		if finished := stat.SuccessCount + stat.ErrorCount; finished > 0 {
+			studentStat.ErrorRatio = float64(stat.ErrorCount) / float64(finished)
+		}
+		studentStats = append(studentStats, studentStat)
+	}
+	return studentStats, nil
+}
@@ -0,0 +1,94 @@
+package execution
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	ArtifactDir  = "/output"
+	MaxArtifacts = 20
+
+	// images up to this size are pushed inline over WebSocket, larger ones are download only
+	MaxPushedImageBytes = 2 * 1024 * 1024
+)
+
+type Artifact struct {
+	Path     string `json:"path"` // relative to /output
+	MIMEType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	Content  []byte `json:"-"`
+}
+
+func (a *Artifact) IsImage() bool {
+	return strings.HasPrefix(a.MIMEType, "image/")
+}
+
+// collectArtifacts copies the files the program left in /output. The tmpfs size
+// already bounds the total, limitMB is checked again while reading the archive.
+func collectArtifacts(sb sandbox, limitMB int) ([]Artifact, error) {
+	if limitMB <= 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	archive, stderr, _, err := sb.Exec(ctx, []string{"tar", "-C", ArtifactDir, "-cf", "-", "."}, "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%v %s", err, strings.TrimSpace(stderr))
+	}
+	return readArtifactArchive(strings.NewReader(archive), int64(limitMB)*1024*1024)
+}
+
+func readArtifactArchive(archive io.Reader, limitBytes int64) ([]Artifact, error) {
+	artifacts := make([]Artifact, 0)
+	reader := tar.NewReader(archive)
+	var total int64
+	for len(artifacts) < MaxArtifacts {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return artifacts, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue // directories, links and devices are never returned
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if strings.HasPrefix(name, ".") || strings.Contains(name, "/.") {
+			continue // hidden files such as caches
+		}
+		if total+header.Size > limitBytes {
+			break
+		}
+
+		var content bytes.Buffer
+		if _, err := io.CopyN(&content, reader, header.Size); err != nil {
+			return artifacts, err
+		}
+		total += header.Size
+		artifacts = append(artifacts, Artifact{
+			Path:     name,
+			MIMEType: detectMIMEType(name, content.Bytes()),
+			Size:     header.Size,
+			Content:  content.Bytes(),
+		})
+	}
+	return artifacts, nil
+}
+
+func detectMIMEType(name string, content []byte) string {
+	if mimeType := mime.TypeByExtension(path.Ext(name)); mimeType != "" {
+		return mimeType
+	}
+	return http.DetectContentType(content)
+}
@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+const (
+	BackendDockerCLI = "docker" // the docker CLI with the workspace bind-mounted from /host/tmp
+	BackendEngine    = "engine" // the Engine API on DOCKER_SOCKET, the workspace copied in
+	BackendLocal     = "local"  // child processes with rlimits, for machines without Docker
+)
+
+// dockerChecker is implemented by the backends that need docker images, it
+// verifies every image of ConfiguredImages.
+type dockerChecker interface {
+	CheckDockerAvailable() error
+}
+
+// poolReporter is implemented by the backends that keep warm containers.
+type poolReporter interface {
+	PoolStats() map[string]interface{}
+}
+
+// newConfiguredExecutor builds the backend named by EXECUTION_BACKEND.
+func newConfiguredExecutor() Executor {
+	var executor Executor
+	switch backend := os.Getenv("EXECUTION_BACKEND"); backend {
+	case BackendEngine:
+		socket := os.Getenv("DOCKER_SOCKET")
+		if socket == "" {
+			socket = DefaultDockerSocket
+		}
+		engineExecutor := NewEngineExecutor(NewEngineClient(socket))
+		engineExecutor.EnablePool(configuredPoolSize())
+		executor = engineExecutor
+	case BackendLocal:
+		log.Printf("WARNING: code runs as local processes, without container isolation")
+		executor = NewLocalExecutor()
+	case "", BackendDockerCLI:
+		executor = NewDockerExecutor()
+	default:
+		log.Printf("WARNING: unknown EXECUTION_BACKEND %q, using %s", backend, BackendDockerCLI)
+		executor = NewDockerExecutor()
+	}
+	return executor
+}
+
+// configuredPoolSize reads EXECUTION_POOL_SIZE, the warm containers per language.
+func configuredPoolSize() int {
+	value := os.Getenv("EXECUTION_POOL_SIZE")
+	if value == "" {
+		return DefaultPoolSize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		log.Printf("WARNING: invalid EXECUTION_POOL_SIZE %q, using %d", value, DefaultPoolSize)
+		return DefaultPoolSize
+	}
+	return size
+}
+
+// configuredQueueConfig reads the worker pool and the queue limits, each
+// variable overrides one field of DefaultQueueConfig.
+func configuredQueueConfig() QueueConfig {
+	config := DefaultQueueConfig
+	config.Workers = positiveEnv("EXECUTION_WORKERS", config.Workers)
+	config.MaxQueueSize = positiveEnv("EXECUTION_MAX_QUEUE_SIZE", config.MaxQueueSize)
+	config.MaxQueuedPerUser = positiveEnv("EXECUTION_MAX_QUEUED_PER_USER", config.MaxQueuedPerUser)
+	config.PerUserLimit = positiveEnv("EXECUTION_PER_USER_LIMIT", config.PerUserLimit)
+	config.PerLectureLimit = positiveEnv("EXECUTION_PER_LECTURE_LIMIT", config.PerLectureLimit)
+	return config
+}
+
+func positiveEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		log.Printf("WARNING: invalid %s %q, using %d", name, value, fallback)
+		return fallback
+	}
+	return number
+}
@@ -0,0 +1,61 @@
+package execution
+
+import "container/list"
+
+// resultCache keeps every active execution plus the most recently finished
+// ones; older finished results are only available from the executions table.
+// It is not safe for concurrent use, ExecutionManager guards it with its mutex.
+type resultCache struct {
+	capacity int
+	results  map[string]*ExecutionResult
+	finished *list.List // finished execution ids, oldest first
+	elements map[string]*list.Element
+}
+
+func newResultCache(capacity int) *resultCache {
+	return &resultCache{
+		capacity: capacity,
+		results:  make(map[string]*ExecutionResult),
+		finished: list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (rc *resultCache) put(result *ExecutionResult) {
+	rc.results[result.ID] = result
+}
+
+func (rc *resultCache) get(id string) (*ExecutionResult, bool) {
+	result, exists := rc.results[id]
+	return result, exists
+}
+
+// markFinished makes the result evictable and drops the oldest finished
+// results beyond capacity.
+func (rc *resultCache) markFinished(id string) {
+	if _, exists := rc.results[id]; !exists {
+		return
+	}
+	if element, exists := rc.elements[id]; exists {
+		rc.finished.MoveToBack(element)
+	} else {
+		rc.elements[id] = rc.finished.PushBack(id)
+	}
+
+	for rc.finished.Len() > rc.capacity {
+		oldest := rc.finished.Front()
+		rc.remove(oldest.Value.(string))
+	}
+}
+
+func (rc *resultCache) remove(id string) {
+	if element, exists := rc.elements[id]; exists {
+		rc.finished.Remove(element)
+		delete(rc.elements, id)
+	}
+	delete(rc.results, id)
+}
+
+func (rc *resultCache) len() int {
+	return len(rc.results)
+}
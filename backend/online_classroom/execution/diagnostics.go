@@ -0,0 +1,38 @@
+package execution
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gcc, javac and the go toolchain all report "file:line[:col]: [severity:] message"
+var diagnosticPattern = regexp.MustCompile(`^(?:\./)?([^\s:]+):(\d+)(?::(\d+))?: (?:(error|warning|note): )?(.+)$`)
+
+func parseDiagnostics(compileOutput string) []CompileDiagnostic {
+	diagnostics := make([]CompileDiagnostic, 0)
+
+	for _, line := range strings.Split(compileOutput, "\n") {
+		matches := diagnosticPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if matches == nil {
+			continue
+		}
+
+		lineNo, _ := strconv.Atoi(matches[2])
+		column, _ := strconv.Atoi(matches[3])
+		severity := matches[4]
+		if severity == "" {
+			severity = "error"
+		}
+
+		diagnostics = append(diagnostics, CompileDiagnostic{
+			File:     strings.TrimPrefix(matches[1], "/app/"),
+			Line:     lineNo,
+			Column:   column,
+			Severity: severity,
+			Message:  strings.TrimSpace(matches[5]),
+		})
+	}
+
+	return diagnostics
+}
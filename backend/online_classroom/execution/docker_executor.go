@@ -0,0 +1,312 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DockerExecutor struct{}
+
+func NewDockerExecutor() *DockerExecutor {
+	return &DockerExecutor{}
+}
+
+func (de *DockerExecutor) Execute(task *ExecutionTask) (*ExecutionResult, error) {
+	startTime := time.Now()
+	lang := task.Language
+
+	result, ok := beginExecution(task, startTime)
+	if !ok {
+		return result, nil
+	}
+
+	workspaceDir, err := de.createTempWorkspace(task.Files, lang)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("Failed to create temp file: %v", err)
+		result.DurationMS = time.Since(startTime).Milliseconds()
+		return result, nil
+	}
+	defer os.RemoveAll(workspaceDir)
+
+	containerName := "zcode_" + result.ID
+	de.runInSandbox(containerName, workspaceDir, task, result)
+	result.DurationMS = time.Since(startTime).Milliseconds()
+
+	return result, nil
+}
+
+// runInSandbox keeps one idle container alive for the whole pipeline and runs
+// each phase through docker exec, so the build output in /tmp and the files in
+// /output survive between phases while each phase still gets its own timeout
+// and its own output.
+func (de *DockerExecutor) runInSandbox(containerName string, workspaceDir string, task *ExecutionTask, result *ExecutionResult) {
+	lang := task.Language
+	config := task.Config
+	lifetime := config.CompileTimeoutSeconds + config.TimeoutSeconds + 10
+	startArgs := []string{"run", "-d", "--rm", "--name", containerName}
+	startArgs = append(startArgs, sandboxArgs(workspaceDir, lang, config)...)
+	startArgs = append(startArgs, lang.Image, "sleep", strconv.Itoa(lifetime))
+
+	startCtx, startCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	_, startStderr, _, err := runDockerCommand(startCtx, startArgs, "", nil)
+	startCancel()
+	if err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("Failed to start sandbox: %v %s", err, strings.TrimSpace(startStderr))
+		return
+	}
+	defer removeContainer(containerName)
+
+	runPipeline(&cliSandbox{containerName: containerName}, task, result)
+}
+
+// startInteractive runs the language's interpreter attached to a pipe in a
+// container with an empty workspace, for a REPL session.
+func (de *DockerExecutor) startInteractive(name string, lang *LanguageSpec, config ExecutionConfig, stdout io.Writer, stderr io.Writer) (*interactiveProcess, error) {
+	workspaceDir, err := de.createTempWorkspace(nil, lang)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"run", "-i", "--rm", "--name", name}
+	args = append(args, sandboxArgs(workspaceDir, lang, config)...)
+	args = append(args, lang.Image)
+	args = append(args, lang.ReplCmd...)
+
+	cmd := exec.Command("docker", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		os.RemoveAll(workspaceDir)
+		return nil, err
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(workspaceDir)
+		return nil, err
+	}
+
+	return &interactiveProcess{
+		Stdin: stdin,
+		Wait: func() error {
+			defer os.RemoveAll(workspaceDir)
+			return cmd.Wait()
+		},
+		// killing the docker CLI would leave the container running
+		Stop: func() { removeContainer(name) },
+	}, nil
+}
+
+// cliSandbox runs the phases with the docker CLI.
+type cliSandbox struct {
+	containerName string
+}
+
+func (cs *cliSandbox) Exec(ctx context.Context, command []string, stdin string, onOutput func(stream string, chunk string), budget *outputBudget) (string, string, int, error) {
+	args := append([]string{"exec", "-i", cs.containerName}, command...)
+	return runLimitedDockerCommand(ctx, args, stdin, onOutput, budget)
+}
+
+func (cs *cliSandbox) StopProcesses() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	args := append([]string{"exec", cs.containerName}, stopProcessesCommand...)
+	if err := exec.CommandContext(ctx, "docker", args...).Run(); err != nil {
+		log.Printf("WARNING: failed to stop the program in %s: %v", cs.containerName, err)
+	}
+}
+
+func sandboxArgs(workspaceDir string, lang *LanguageSpec, config ExecutionConfig) []string {
+	args := []string{
+		"--network", "none",
+		"--read-only",
+//		"--tmpfs", "/tmp:rw,noexec,nosuid,size=10m",
+		"--user", "nobody",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+	}
+
+	for target, options := range sandboxTmpfs(lang, config) {
+		args = append(args, "--tmpfs", target+":"+options)
+	}
+	for _, env := range sandboxEnv(lang, config) {
+		args = append(args, "-e", env)
+	}
+
+	if config.MemoryLimitMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", config.MemoryLimitMB))
+	}
+	if config.CPULimit > 0 {
+		args = append(args, "--cpus", fmt.Sprintf("%.2f", config.CPULimit))
+	}
+	mountSource := strings.Replace(workspaceDir, "/host/tmp/", "/tmp/", 1)
+
+	// working directory /app so programs open their data files by relative path
+	return append(args, "-v", fmt.Sprintf("%s:/app:ro", mountSource), "-w", "/app")
+}
+
+func runDockerCommand(ctx context.Context, args []string, stdin string, onOutput func(stream string, chunk string)) (string, string, int, error) {
+	return runLimitedDockerCommand(ctx, args, stdin, onOutput, nil)
+}
+
+// runLimitedDockerCommand is runDockerCommand with the output bounded by budget,
+// which may be nil for commands whose output we produce ourselves.
+func runLimitedDockerCommand(ctx context.Context, args []string, stdin string, onOutput func(stream string, chunk string), budget *outputBudget) (string, string, int, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	stdout := newLimitedStreamWriter("stdout", onOutput, budget)
+	stderr := newLimitedStreamWriter("stderr", onOutput, budget)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+
+	exitCode := 0
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			exitCode = exitError.ExitCode()
+		} else {
+			exitCode = -1
+		}
+	}
+	return stdout.String(), stderr.String(), exitCode, err
+}
+
+// compileOutputSink tags everything the compiler prints as the "compile" stream.
+func compileOutputSink(onOutput func(stream string, chunk string)) func(stream string, chunk string) {
+	if onOutput == nil {
+		return nil
+	}
+	return func(stream string, chunk string) {
+		onOutput("compile", chunk)
+	}
+}
+
+// removeContainer is needed on timeout: killing the docker CLI does not stop the container.
+func removeContainer(containerName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := exec.CommandContext(ctx, "docker", "rm", "-f", containerName).Run(); err != nil {
+		log.Printf("WARNING: failed to remove container %s: %v", containerName, err)
+	}
+}
+
+func markStdinExhausted(result *ExecutionResult, lang *LanguageSpec) {
+	if result.ExitCode == 0 {
+		return
+	}
+	for _, pattern := range lang.EOFPatterns {
+		if strings.Contains(result.Output, pattern) {
+			result.StdinExhausted = true
+			result.Error = "program tried to read more input than was provided on stdin"
+			return
+		}
+	}
+}
+
+func joinOutput(stdout, stderr string) string {
+	output := stdout
+	if stderr != "" {
+		if output != "" {
+			output += "\n--- STDERR ---\n"
+		}
+		output += stderr
+	}
+	return output
+}
+
+// beginExecution creates the result of a task and validates its code. When the
+// code is rejected the result is already final and ok is false.
+func beginExecution(task *ExecutionTask, startTime time.Time) (*ExecutionResult, bool) {
+	result := &ExecutionResult{
+		ID:         generateExecutionID(),
+		Code:       task.Code,
+		Language:   task.Language.Name,
+		ExecutedAt: startTime,
+		Status:     "running",
+	}
+
+	if err := validateCode(task); err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("Security violation: %v", err)
+		if policyErr, ok := err.(*PolicyError); ok {
+			result.Violations = policyErr.Violations
+		}
+		result.DurationMS = time.Since(startTime).Milliseconds()
+		return result, false
+	}
+	return result, true
+}
+
+func validateCode(task *ExecutionTask) error {
+	lang := task.Language
+	if strings.TrimSpace(workspaceEntryCode(task.Files, task.Entrypoint)) == "" {
+		return fmt.Errorf("empty code")
+	}
+
+	policy := task.Policy
+	if policy == nil {
+		policy = DefaultCodePolicy
+	}
+	violations := make([]PolicyViolation, 0)
+	checked := make([]WorkspaceFile, 0, len(task.Files))
+	for _, file := range task.Files {
+		if file.Trusted {
+			continue
+		}
+		checked = append(checked, file)
+		// data files are only bound by the workspace size cap
+		if path.Ext(file.Path) == path.Ext(lang.SourceFile) {
+			violations = append(violations, policy.checkSize(file)...)
+		}
+	}
+	if lang.Validate != nil {
+		violations = append(violations, lang.Validate(checked, policy)...)
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Policy: policy.Name, Violations: violations}
+	}
+
+	return nil
+}
+
+func (de *DockerExecutor) CheckDockerAvailable() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "docker", "--version")
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("docker is not available: %v", err)
+	}
+
+	missing := make([]string, 0)
+	for _, image := range ConfiguredImages() {
+		cmd = exec.CommandContext(ctx, "docker", "image", "inspect", image)
+		if err := cmd.Run(); err != nil {
+			missing = append(missing, image)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("images not found: %s, please run: docker pull <image>", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func generateExecutionID() string {
+	return fmt.Sprintf("exec_%d_%d", time.Now().Unix(), time.Now().Nanosecond())
+}
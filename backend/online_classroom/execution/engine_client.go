@@ -0,0 +1,292 @@
+package execution
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	DefaultDockerSocket = "/var/run/docker.sock"
+	engineAPIVersion    = "v1.41"
+)
+
+// ContainerEngine is the part of the Docker Engine API the EngineExecutor needs.
+// EngineClient implements it over the daemon's unix socket, FakeEngine in tests.
+type ContainerEngine interface {
+	Ping(ctx context.Context) error
+	ImageExists(ctx context.Context, image string) (bool, error)
+	CreateContainer(ctx context.Context, spec ContainerSpec) (string, error)
+	StartContainer(ctx context.Context, id string) error
+	// Exec runs a command in a running container and returns its exit code once
+	// it has finished. stdin is closed after it is drained.
+	Exec(ctx context.Context, id string, spec ExecSpec, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error)
+	RemoveContainer(ctx context.Context, id string) error
+}
+
+type ContainerSpec struct {
+	Name        string
+	Image       string
+	Cmd         []string
+	User        string
+	WorkingDir  string
+	Env         []string
+	Labels      map[string]string
+	Tmpfs       map[string]string // mount point -> mount options
+	MemoryBytes int64
+	NanoCPUs    int64
+}
+
+type ExecSpec struct {
+	Cmd  []string
+	User string // empty runs as the container's user
+}
+
+type EngineClient struct {
+	socketPath string
+	client     *http.Client
+}
+
+func NewEngineClient(socketPath string) *EngineClient {
+	ec := &EngineClient{socketPath: socketPath}
+	ec.client = &http.Client{
+		Transport: &http.Transport{DialContext: ec.dial},
+	}
+	return ec
+}
+
+func (ec *EngineClient) dial(ctx context.Context, _ string, _ string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", ec.socketPath)
+}
+
+type engineError struct {
+	Message string `json:"message"`
+}
+
+// do sends a JSON request and decodes a JSON answer into out when it is not nil.
+// Status codes in accept are not errors.
+func (ec *EngineClient) do(ctx context.Context, method string, apiPath string, body interface{}, out interface{}, accept ...int) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://docker/"+engineAPIVersion+apiPath, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := ec.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("docker engine: %v", err)
+	}
+	defer resp.Body.Close()
+
+	for _, code := range accept {
+		if resp.StatusCode == code {
+			return resp.StatusCode, nil
+		}
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr engineError
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return resp.StatusCode, fmt.Errorf("docker engine: %s %s: %d %s", method, apiPath, resp.StatusCode, apiErr.Message)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("docker engine: decoding %s: %v", apiPath, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func (ec *EngineClient) Ping(ctx context.Context) error {
+	_, err := ec.do(ctx, http.MethodGet, "/_ping", nil, nil)
+	return err
+}
+
+func (ec *EngineClient) ImageExists(ctx context.Context, image string) (bool, error) {
+	status, err := ec.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, http.StatusNotFound)
+	if err != nil {
+		return false, err
+	}
+	return status != http.StatusNotFound, nil
+}
+
+func (ec *EngineClient) CreateContainer(ctx context.Context, spec ContainerSpec) (string, error) {
+	body := map[string]interface{}{
+		"Image":           spec.Image,
+		"Cmd":             spec.Cmd,
+		"User":            spec.User,
+		"WorkingDir":      spec.WorkingDir,
+		"Env":             spec.Env,
+		"Labels":          spec.Labels,
+		"NetworkDisabled": true,
+		"HostConfig": map[string]interface{}{
+			"NetworkMode":    "none",
+			"ReadonlyRootfs": true,
+			"Tmpfs":          spec.Tmpfs,
+			"CapDrop":        []string{"ALL"},
+			"SecurityOpt":    []string{"no-new-privileges"},
+			"Memory":         spec.MemoryBytes,
+			"MemorySwap":     spec.MemoryBytes, // no swap, so the limit is the limit
+			"NanoCpus":       spec.NanoCPUs,
+			"AutoRemove":     true,
+		},
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if _, err := ec.do(ctx, http.MethodPost, "/containers/create?name="+url.QueryEscape(spec.Name), body, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (ec *EngineClient) StartContainer(ctx context.Context, id string) error {
+	_, err := ec.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil)
+	return err
+}
+
+// RemoveContainer kills and removes the container. A container that is already
+// gone (AutoRemove after its sleep ended) is not an error.
+func (ec *EngineClient) RemoveContainer(ctx context.Context, id string) error {
+	_, err := ec.do(ctx, http.MethodDelete, "/containers/"+id+"?force=true&v=true", nil, nil, http.StatusNotFound, http.StatusConflict)
+	return err
+}
+
+func (ec *EngineClient) Exec(ctx context.Context, id string, spec ExecSpec, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	body := map[string]interface{}{
+		"AttachStdin":  true,
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Tty":          false,
+		"Cmd":          spec.Cmd,
+		"User":         spec.User,
+	}
+	if _, err := ec.do(ctx, http.MethodPost, "/containers/"+id+"/exec", body, &created); err != nil {
+		return -1, err
+	}
+
+	if err := ec.attachExec(ctx, created.ID, stdin, stdout, stderr); err != nil {
+		return -1, err
+	}
+
+	// the stream ends before the daemon has recorded the exit code
+	inspectCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for {
+		var inspected struct {
+			Running  bool `json:"Running"`
+			ExitCode int  `json:"ExitCode"`
+		}
+		if _, err := ec.do(inspectCtx, http.MethodGet, "/exec/"+created.ID+"/json", nil, &inspected); err != nil {
+			return -1, err
+		}
+		if !inspected.Running {
+			return inspected.ExitCode, nil
+		}
+		select {
+		case <-inspectCtx.Done():
+			return -1, inspectCtx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+// attachExec starts the exec on a hijacked connection: stdin is written to the
+// raw socket, and stdout/stderr come back multiplexed in frames with an 8 byte
+// header (stream type, 3 bytes padding, big-endian payload size).
+func (ec *EngineClient) attachExec(ctx context.Context, execID string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	conn, err := ec.dial(ctx, "", "")
+	if err != nil {
+		return fmt.Errorf("docker engine: %v", err)
+	}
+	defer conn.Close()
+
+	// closing the connection is the only way to interrupt the blocking reads below
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, "http://docker/"+engineAPIVersion+"/exec/"+execID+"/start", bytes.NewReader([]byte(`{"Detach":false,"Tty":false}`)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	if err := req.Write(conn); err != nil {
+		return ec.interrupted(ctx, err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return ec.interrupted(ctx, err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		var apiErr engineError
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("docker engine: exec start: %d %s", resp.StatusCode, apiErr.Message)
+	}
+
+	go func() {
+		if stdin != nil {
+			io.Copy(conn, stdin)
+		}
+		// half-close so the program reads EOF, the output keeps flowing
+		if unixConn, ok := conn.(*net.UnixConn); ok {
+			unixConn.CloseWrite()
+		}
+	}()
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return ec.interrupted(ctx, err)
+		}
+		var target io.Writer = stdout
+		if header[0] == 2 {
+			target = stderr
+		}
+		if _, err := io.CopyN(target, reader, int64(binary.BigEndian.Uint32(header[4:]))); err != nil {
+			return ec.interrupted(ctx, err)
+		}
+	}
+}
+
+// interrupted reports a connection error caused by cancelling ctx as ctx's error.
+func (ec *EngineClient) interrupted(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("docker engine: %v", err)
+}
@@ -0,0 +1,288 @@
+package execution
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// workspaceTmpfs holds the copied workspace at /app. It is owned by root, so the
+// program running as nobody can read the files but not change them.
+const workspaceTmpfs = "rw,noexec,nosuid,size=4m,mode=755"
+
+// EngineExecutor runs code through the Docker Engine API. Unlike DockerExecutor
+// it needs neither the docker CLI nor a host directory shared with the daemon:
+// the workspace is copied into the container as a tar stream.
+type EngineExecutor struct {
+	engine ContainerEngine
+	pool   *ContainerPool // nil when every run starts its own container
+}
+
+func NewEngineExecutor(engine ContainerEngine) *EngineExecutor {
+	return &EngineExecutor{engine: engine}
+}
+
+// EnablePool keeps size warm containers per language, 0 disables the pool.
+func (ee *EngineExecutor) EnablePool(size int) {
+	if size <= 0 {
+		return
+	}
+	ee.pool = NewContainerPool(ee.engine, size)
+	ee.pool.Start()
+}
+
+// PoolStats reports the health of the warm pool.
+func (ee *EngineExecutor) PoolStats() map[string]interface{} {
+	if ee.pool == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	return ee.pool.GetStats()
+}
+
+func (ee *EngineExecutor) Execute(task *ExecutionTask) (*ExecutionResult, error) {
+	startTime := time.Now()
+
+	result, ok := beginExecution(task, startTime)
+	if !ok {
+		return result, nil
+	}
+
+	ee.runInSandbox("zcode_"+result.ID, task, result)
+	result.DurationMS = time.Since(startTime).Milliseconds()
+
+	return result, nil
+}
+
+func (ee *EngineExecutor) runInSandbox(containerName string, task *ExecutionTask, result *ExecutionResult) {
+	startCtx, startCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer startCancel()
+
+	containerID, err := ee.acquireContainer(startCtx, containerName, task)
+	if containerID != "" {
+		// pooled containers are single use as well
+		defer ee.removeContainer(containerID)
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("Failed to start sandbox: %v", err)
+		return
+	}
+
+	sb := &engineSandbox{engine: ee.engine, containerID: containerID}
+	if err := sb.copyWorkspace(startCtx, task.Files); err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("Failed to copy the workspace: %v", err)
+		return
+	}
+	startCancel()
+
+	runPipeline(sb, task, result)
+}
+
+// acquireContainer takes a warm container from the pool or starts a new one.
+// A container that was created but failed to start is returned with the error,
+// so the caller removes it.
+func (ee *EngineExecutor) acquireContainer(ctx context.Context, containerName string, task *ExecutionTask) (string, error) {
+	if ee.pool != nil {
+		if containerID, ok := ee.pool.Acquire(task.Language, task.Config); ok {
+			return containerID, nil
+		}
+	}
+
+	config := task.Config
+	lifetime := time.Duration(config.CompileTimeoutSeconds+config.TimeoutSeconds+10) * time.Second
+	containerID, err := ee.engine.CreateContainer(ctx, containerSpec(containerName, task.Language, config, lifetime))
+	if err != nil {
+		return "", err
+	}
+	return containerID, ee.engine.StartContainer(ctx, containerID)
+}
+
+// startInteractive starts a container for a REPL session and attaches the
+// interpreter to a pipe through an exec.
+func (ee *EngineExecutor) startInteractive(name string, lang *LanguageSpec, config ExecutionConfig, stdout io.Writer, stderr io.Writer) (*interactiveProcess, error) {
+	startCtx, startCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer startCancel()
+
+	containerID, err := ee.engine.CreateContainer(startCtx, containerSpec(name, lang, config, ReplMaxLifetime+time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	if err := ee.engine.StartContainer(startCtx, containerID); err != nil {
+		ee.removeContainer(containerID)
+		return nil, err
+	}
+
+	stdinReader, stdinWriter := io.Pipe()
+	execCtx, execCancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := ee.engine.Exec(execCtx, containerID, ExecSpec{Cmd: lang.ReplCmd}, stdinReader, stdout, stderr)
+		done <- err
+	}()
+
+	return &interactiveProcess{
+		Stdin: stdinWriter,
+		Wait: func() error {
+			err := <-done
+			if execCtx.Err() != nil {
+				return nil // stopped by us
+			}
+			return err
+		},
+		Stop: func() {
+			execCancel()
+			stdinReader.Close()
+			ee.removeContainer(containerID)
+		},
+	}, nil
+}
+
+// containerSpec describes an idle sandbox that sleeps for lifetime, the upper
+// bound on how long a container outlives a crashed backend.
+func containerSpec(name string, lang *LanguageSpec, config ExecutionConfig, lifetime time.Duration) ContainerSpec {
+	tmpfs := sandboxTmpfs(lang, config)
+	tmpfs["/app"] = workspaceTmpfs
+	return ContainerSpec{
+		Name:        name,
+		Image:       lang.Image,
+		Cmd:         []string{"sleep", fmt.Sprint(int(lifetime.Seconds()))},
+		User:        "nobody",
+		WorkingDir:  "/app",
+		Env:         sandboxEnv(lang, config),
+		Labels:      map[string]string{"zcode.sandbox": lang.Name},
+		Tmpfs:       tmpfs,
+		MemoryBytes: int64(config.MemoryLimitMB) * 1024 * 1024,
+		NanoCPUs:    int64(config.CPULimit * 1e9),
+	}
+}
+
+// removeContainer uses its own context: it also has to run after a timeout or
+// a cancellation has ended the execution's context.
+func (ee *EngineExecutor) removeContainer(containerID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := ee.engine.RemoveContainer(ctx, containerID); err != nil {
+		log.Printf("WARNING: failed to remove container %s: %v", containerID, err)
+	}
+}
+
+func (ee *EngineExecutor) CheckDockerAvailable() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := ee.engine.Ping(ctx); err != nil {
+		return fmt.Errorf("docker engine is not available: %v", err)
+	}
+
+	missing := make([]string, 0)
+	for _, image := range ConfiguredImages() {
+		if exists, err := ee.engine.ImageExists(ctx, image); err != nil || !exists {
+			missing = append(missing, image)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("images not found: %s, please run: docker pull <image>", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// engineSandbox runs the phases through the Engine API exec endpoints.
+type engineSandbox struct {
+	engine      ContainerEngine
+	containerID string
+}
+
+func (es *engineSandbox) Exec(ctx context.Context, command []string, stdin string, onOutput func(stream string, chunk string), budget *outputBudget) (string, string, int, error) {
+	stdout := newLimitedStreamWriter("stdout", onOutput, budget)
+	stderr := newLimitedStreamWriter("stderr", onOutput, budget)
+
+	exitCode, err := es.engine.Exec(ctx, es.containerID, ExecSpec{Cmd: command}, strings.NewReader(stdin), stdout, stderr)
+	stdout.Flush()
+	stderr.Flush()
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("exit status %d", exitCode)
+	}
+	return stdout.String(), stderr.String(), exitCode, err
+}
+
+func (es *engineSandbox) StopProcesses() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := es.engine.Exec(ctx, es.containerID, ExecSpec{Cmd: stopProcessesCommand}, nil, io.Discard, io.Discard); err != nil {
+		log.Printf("WARNING: failed to stop the program in %s: %v", es.containerID, err)
+	}
+}
+
+// copyWorkspace unpacks the files into /app. tar runs as root, the owner of the
+// tmpfs, because the rootfs is read-only and the archive endpoint refuses it.
+func (es *engineSandbox) copyWorkspace(ctx context.Context, files []WorkspaceFile) error {
+	archive, err := workspaceArchive(files)
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	spec := ExecSpec{Cmd: []string{"tar", "-x", "-f", "-", "-C", "/app"}, User: "root"}
+	exitCode, err := es.engine.Exec(ctx, es.containerID, spec, bytes.NewReader(archive), io.Discard, &stderr)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("tar exited with %d: %s", exitCode, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// workspaceArchive packs the workspace as a tar stream, world-readable and owned by root.
+func workspaceArchive(files []WorkspaceFile) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	modTime := time.Now()
+
+	// explicit directory entries, so every directory is readable by nobody
+	// whatever umask tar would give the implicit ones
+	seen := make(map[string]bool)
+	dirs := make([]string, 0)
+	for _, file := range files {
+		for dir := path.Dir(file.Path); dir != "." && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs) // parents before their children
+	for _, dir := range dirs {
+		if err := writer.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0755, ModTime: modTime}); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.Path,
+			Mode:     0644,
+			Size:     int64(len(file.Content)),
+			ModTime:  modTime,
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write([]byte(file.Content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,164 @@
+package execution
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func engineTask(t *testing.T, language string, files []WorkspaceFile, entrypoint string) *ExecutionTask {
+	t.Helper()
+	lang, err := GlobalLanguageRegistry.Get(language)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ExecutionTask{
+		Code:       workspaceEntryCode(files, entrypoint),
+		Files:      files,
+		Entrypoint: entrypoint,
+		Language:   lang,
+		Config:     lang.DefaultConfig,
+	}
+}
+
+func TestEngineExecutorRunsProgram(t *testing.T) {
+	engine := NewFakeEngine()
+	engine.Handler = func(cmd []string, stdin string, files map[string]string) (string, string, int) {
+		if strings.Join(cmd, " ") != "python /app/main.py" {
+			return "", "unexpected command " + strings.Join(cmd, " "), 1
+		}
+		return "hello " + stdin, "", 0
+	}
+	executor := NewEngineExecutor(engine)
+
+	task := engineTask(t, "python", []WorkspaceFile{{Path: "main.py", Content: "print('hello', input())"}}, "main.py")
+	task.Stdin = "world"
+	result, err := executor.Execute(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != "completed" || result.ExitCode != 0 {
+		t.Fatalf("status %s, exit code %d, error %q", result.Status, result.ExitCode, result.Error)
+	}
+	if result.Stdout != "hello world" {
+		t.Errorf("stdout %q", result.Stdout)
+	}
+	if running := engine.Running(); running != 0 {
+		t.Errorf("%d containers still running", running)
+	}
+	containers := engine.Containers()
+	if len(containers) != 1 {
+		t.Fatalf("created %d containers, want 1", len(containers))
+	}
+	if containers[0].User != "nobody" || containers[0].Tmpfs["/app"] != workspaceTmpfs {
+		t.Errorf("container spec %+v", containers[0])
+	}
+}
+
+func TestEngineExecutorTimeoutRemovesContainer(t *testing.T) {
+	engine := NewFakeEngine()
+	engine.Delay = 10 * time.Second
+	executor := NewEngineExecutor(engine)
+
+	task := engineTask(t, "python", []WorkspaceFile{{Path: "main.py", Content: "while True: pass"}}, "main.py")
+	task.Config.TimeoutSeconds = 1
+	started := time.Now()
+	result, err := executor.Execute(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("the run took %v, the timeout is 1s", elapsed)
+	}
+	if result.Status != "timeout" || !result.TimedOut || result.ExitCode != -1 {
+		t.Fatalf("status %s, timed out %v, exit code %d", result.Status, result.TimedOut, result.ExitCode)
+	}
+	if running := engine.Running(); running != 0 {
+		t.Errorf("%d containers still running after the timeout", running)
+	}
+
+	stopped := false
+	for _, cmd := range engine.Execs(engine.Containers()[0].Name) {
+		if strings.Join(cmd, " ") == strings.Join(stopProcessesCommand, " ") {
+			stopped = true
+		}
+	}
+	if !stopped {
+		t.Error("the program was not stopped inside the container")
+	}
+}
+
+func TestEngineExecutorCopiesWorkspace(t *testing.T) {
+	var copied map[string]string
+	engine := NewFakeEngine()
+	engine.Handler = func(cmd []string, stdin string, files map[string]string) (string, string, int) {
+		copied = make(map[string]string, len(files))
+		for name, content := range files {
+			copied[name] = content
+		}
+		return "", "", 0
+	}
+	executor := NewEngineExecutor(engine)
+
+	files := []WorkspaceFile{
+		{Path: "main.py", Content: "from pkg.util import f\nf()"},
+		{Path: "pkg/util.py", Content: "def f():\n    pass\n"},
+		{Path: "pkg/data/input.txt", Content: "1 2 3"},
+	}
+	result, err := executor.Execute(engineTask(t, "python", files, "main.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "completed" {
+		t.Fatalf("status %s, error %q", result.Status, result.Error)
+	}
+
+	if len(copied) != len(files) {
+		t.Errorf("copied %d files, want %d: %v", len(copied), len(files), copied)
+	}
+	for _, file := range files {
+		if content := copied["/app/"+file.Path]; content != file.Content {
+			t.Errorf("/app/%s holds %q, want %q", file.Path, content, file.Content)
+		}
+	}
+
+	execs := engine.Execs(engine.Containers()[0].Name)
+	if len(execs) == 0 || strings.Join(execs[0], " ") != "tar -x -f - -C /app" {
+		t.Errorf("the workspace was not copied first, execs %v", execs)
+	}
+}
+
+func TestEngineExecutorUsesPooledContainer(t *testing.T) {
+	engine := NewFakeEngine()
+	executor := NewEngineExecutor(engine)
+	executor.EnablePool(1)
+
+	languages := len(GlobalLanguageRegistry.List())
+	deadline := time.Now().Add(5 * time.Second)
+	for engine.Running() < languages && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if running := engine.Running(); running != languages {
+		t.Fatalf("%d pooled containers started, want %d", running, languages)
+	}
+
+	result, err := executor.Execute(engineTask(t, "python", []WorkspaceFile{{Path: "main.py", Content: "print(1)"}}, "main.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "completed" {
+		t.Fatalf("status %s, error %q", result.Status, result.Error)
+	}
+
+	for _, spec := range engine.Containers() {
+		if spec.Labels["zcode.pool"] == "" {
+			t.Errorf("the run started container %s instead of taking a pooled one", spec.Name)
+		}
+	}
+	stats := executor.PoolStats()["languages"].(map[string]interface{})["python"].(map[string]interface{})
+	if stats["hits"].(int64) != 1 {
+		t.Errorf("pool stats %v", stats)
+	}
+}
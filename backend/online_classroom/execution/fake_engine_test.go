@@ -0,0 +1,240 @@
+package execution
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeExecHandler plays the program of a fake exec. files holds the container's
+// files by absolute path, a handler may add to it (e.g. /output/plot.png).
+type FakeExecHandler func(cmd []string, stdin string, files map[string]string) (stdout string, stderr string, exitCode int)
+
+// FakeEngine is an in-memory ContainerEngine for exercising the EngineExecutor
+// without a Docker daemon. The sandbox plumbing (workspace copy, cgroup
+// sampling, artifact collection, kill) is emulated, every other command is
+// passed to Handler.
+type FakeEngine struct {
+	Handler FakeExecHandler
+	// Delay makes every program run this long, or until its context ends
+	Delay time.Duration
+	// Stats is what the cgroup sampling reads
+	Stats cgroupStats
+	// MissingImages makes ImageExists report these images as not pulled
+	MissingImages []string
+
+	containers map[string]*fakeContainer
+	nextID     int
+	mutex      sync.Mutex
+}
+
+type fakeContainer struct {
+	spec    ContainerSpec
+	started bool
+	removed bool
+	files   map[string]string
+	execs   [][]string
+}
+
+func NewFakeEngine() *FakeEngine {
+	return &FakeEngine{containers: make(map[string]*fakeContainer)}
+}
+
+func (fe *FakeEngine) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (fe *FakeEngine) ImageExists(ctx context.Context, image string) (bool, error) {
+	for _, missing := range fe.MissingImages {
+		if missing == image {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (fe *FakeEngine) CreateContainer(ctx context.Context, spec ContainerSpec) (string, error) {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+
+	for _, container := range fe.containers {
+		if container.spec.Name == spec.Name && !container.removed {
+			return "", fmt.Errorf("docker engine: container name %s is already in use", spec.Name)
+		}
+	}
+	fe.nextID++
+	id := fmt.Sprintf("fake%04d", fe.nextID)
+	fe.containers[id] = &fakeContainer{spec: spec, files: make(map[string]string)}
+	return id, nil
+}
+
+func (fe *FakeEngine) StartContainer(ctx context.Context, id string) error {
+	container, err := fe.container(id)
+	if err != nil {
+		return err
+	}
+	fe.mutex.Lock()
+	container.started = true
+	fe.mutex.Unlock()
+	return nil
+}
+
+func (fe *FakeEngine) RemoveContainer(ctx context.Context, id string) error {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	if container, exists := fe.containers[id]; exists {
+		container.removed = true
+	}
+	return nil
+}
+
+func (fe *FakeEngine) Exec(ctx context.Context, id string, spec ExecSpec, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	container, err := fe.container(id)
+	if err != nil {
+		return -1, err
+	}
+	var input []byte
+	if stdin != nil {
+		input, _ = io.ReadAll(stdin)
+	}
+
+	fe.mutex.Lock()
+	if !container.started || container.removed {
+		fe.mutex.Unlock()
+		return -1, fmt.Errorf("docker engine: container %s is not running", id)
+	}
+	container.execs = append(container.execs, spec.Cmd)
+	fe.mutex.Unlock()
+
+	switch {
+	case len(spec.Cmd) > 1 && spec.Cmd[0] == "tar" && spec.Cmd[1] == "-x":
+		return fe.extract(container, input, stderr)
+	case len(spec.Cmd) > 1 && spec.Cmd[0] == "tar" && spec.Cmd[1] == "-C":
+		return fe.archiveOutput(container, stdout)
+	case len(spec.Cmd) == 3 && spec.Cmd[2] == cgroupStatsScript:
+		fmt.Fprintf(stdout, "usage_usec %d\npeak_bytes %d\noom_kill %d\n", fe.Stats.cpuUsageMicros, fe.Stats.peakBytes, fe.Stats.oomKills)
+		return 0, nil
+	case strings.Join(spec.Cmd, " ") == strings.Join(stopProcessesCommand, " "):
+		return 0, nil
+	}
+
+	if fe.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-time.After(fe.Delay):
+		}
+	}
+	if fe.Handler == nil {
+		return 0, nil
+	}
+
+	fe.mutex.Lock()
+	out, errOut, exitCode := fe.Handler(spec.Cmd, string(input), container.files)
+	fe.mutex.Unlock()
+	io.WriteString(stdout, out)
+	io.WriteString(stderr, errOut)
+	return exitCode, nil
+}
+
+func (fe *FakeEngine) extract(container *fakeContainer, archive []byte, stderr io.Writer) (int, error) {
+	reader := tar.NewReader(bytes.NewReader(archive))
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "tar: %v", err)
+			return 1, nil
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, _ := io.ReadAll(reader)
+		container.files[path.Join("/app", header.Name)] = string(content)
+	}
+}
+
+func (fe *FakeEngine) archiveOutput(container *fakeContainer, stdout io.Writer) (int, error) {
+	fe.mutex.Lock()
+	names := make([]string, 0)
+	for name := range container.files {
+		if strings.HasPrefix(name, ArtifactDir+"/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	writer := tar.NewWriter(stdout)
+	for _, name := range names {
+		content := container.files[name]
+		writer.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     "./" + strings.TrimPrefix(name, ArtifactDir+"/"),
+			Mode:     0644,
+			Size:     int64(len(content)),
+		})
+		io.WriteString(writer, content)
+	}
+	fe.mutex.Unlock()
+	return 0, writer.Close()
+}
+
+func (fe *FakeEngine) container(id string) (*fakeContainer, error) {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	container, exists := fe.containers[id]
+	if !exists {
+		return nil, fmt.Errorf("docker engine: no such container: %s", id)
+	}
+	return container, nil
+}
+
+// Running is the number of containers that were started and not removed yet.
+func (fe *FakeEngine) Running() int {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	running := 0
+	for _, container := range fe.containers {
+		if container.started && !container.removed {
+			running++
+		}
+	}
+	return running
+}
+
+// Containers returns the specs of every container created so far.
+func (fe *FakeEngine) Containers() []ContainerSpec {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	specs := make([]ContainerSpec, 0, len(fe.containers))
+	for _, container := range fe.containers {
+		specs = append(specs, container.spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// Execs returns the commands run in the container named name, in order.
+func (fe *FakeEngine) Execs(name string) [][]string {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	for _, container := range fe.containers {
+		if container.spec.Name == name {
+			return append([][]string(nil), container.execs...)
+		}
+	}
+	return nil
+}
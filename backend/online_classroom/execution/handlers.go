@@ -1,8 +1,16 @@
 package execution
 
 import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"MScProject/online_classroom/access"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func ExecuteCodeHandler(c *gin.Context) {
@@ -15,7 +23,7 @@ func ExecuteCodeHandler(c *gin.Context) {
 		return
 	}
 
-	if req.Code == "" {
+	if req.Code == "" && len(req.Files) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Code cannot be empty",
@@ -23,20 +31,58 @@ func ExecuteCodeHandler(c *gin.Context) {
 		return
 	}
 
-	if req.Language != "python" {
+	if len(req.Stdin) > MaxStdinBytes {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "Only Python is supported",
+			"error":   "Stdin is too long",
 		})
 		return
 	}
 
-	userRole := c.Query("role")
-	if userRole == "" {
-		userRole = "student"
+	lang, err := GlobalLanguageRegistry.Get(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if _, _, err := NormalizeWorkspace(req, lang); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	userRole, ok := access.ClassRole(req.LectureID, zcode)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You are not a participant of this lecture's class",
+		})
+		return
 	}
+	// runs are recorded for the caller, whatever the body says
+	req.UserZCode = strconv.FormatUint(zcode, 10)
 
-	result, err := GlobalExecutionManager.ExecuteCode(req, userRole)
+	var result *ExecutionResult
+	if req.Async {
+		result, err = GlobalExecutionManager.StartExecution(req, userRole)
+	} else {
+		result, err = GlobalExecutionManager.ExecuteCode(req, userRole)
+	}
+	if err == ErrQueueFull || err == ErrTooManyQueued {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -61,6 +107,70 @@ func GetExecutionResultHandler(c *gin.Context) {
 		return
 	}
 
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	result, err := GlobalExecutionManager.GetResult(executionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Execution result not found",
+		})
+		return
+	}
+	if !canViewExecution(result, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You can not view this execution",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// canViewExecution lets users see their own runs and the lecturer every run of
+// the lecture. The lecturer's runs are shown to the class, so the participants
+// of the class may see them too.
+func canViewExecution(result *ExecutionResult, zcode uint64) bool {
+	if result.UserZCode == strconv.FormatUint(zcode, 10) || access.IsLecturer(result.LectureID, zcode) {
+		return true
+	}
+	ownerID, err := strconv.ParseUint(result.UserZCode, 10, 64)
+	if err != nil || !access.IsLecturer(result.LectureID, ownerID) {
+		return false
+	}
+	_, ok := access.ClassRole(result.LectureID, zcode)
+	return ok
+}
+
+// CanCancelExecution lets users stop their own runs and the lecturer stop any run
+// of the lecture. TAs and the class manager can not stop other people's runs.
+// The HTTP handler and the classroom socket both check it.
+func CanCancelExecution(result *ExecutionResult, zcode uint64) bool {
+	return result.UserZCode == strconv.FormatUint(zcode, 10) || access.IsLecturer(result.LectureID, zcode)
+}
+
+// CancelExecutionHandler lets students stop their own runs and the lecturer stop any run in the lecture.
+func CancelExecutionHandler(c *gin.Context) {
+	executionID := c.Param("id")
+	if executionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Execution ID is required",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
 	result, err := GlobalExecutionManager.GetResult(executionID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -70,8 +180,907 @@ func GetExecutionResultHandler(c *gin.Context) {
 		return
 	}
 
+	if !CanCancelExecution(result, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You can not cancel this execution",
+		})
+		return
+	}
+
+	result, err = GlobalExecutionManager.CancelExecution(executionID)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    result,
 	})
 }
+
+// ExecutionHistoryHandler lists stored executions, newest first. Lecturers may list a
+// whole lecture or any student in it; everybody else only sees their own runs.
+// Query: lecture_id, user_zcode, from, to (RFC3339) and limit.
+func ExecutionHistoryHandler(c *gin.Context) {
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
+	var lectureID uint64
+	var err error
+	if lectureIDStr := c.Query("lecture_id"); lectureIDStr != "" {
+		lectureID, err = strconv.ParseUint(lectureIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid lecture_id",
+			})
+			return
+		}
+	}
+	from, errFrom := parseHistoryTime(c.Query("from"))
+	to, errTo := parseHistoryTime(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "from and to must be RFC3339 timestamps",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	ownZCode := strconv.FormatUint(zcode, 10)
+	userZCode := c.Query("user_zcode")
+	isLecturer := lectureID != 0 && access.IsLecturer(uint(lectureID), zcode)
+	if userZCode == "" && !isLecturer {
+		userZCode = ownZCode
+	}
+	if userZCode != ownZCode && !isLecturer {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the lecturer can view other users' executions",
+		})
+		return
+	}
+
+	var executions []*entities.Execution
+	switch {
+	case userZCode == "":
+		executions, err = configs.ExecutionApplications.FindExecutionsByLectureID(uint(lectureID), from, to, limit)
+	case lectureID != 0:
+		executions, err = configs.ExecutionApplications.FindExecutionsByLectureIDAndUserZCode(uint(lectureID), userZCode, from, to, limit)
+	default:
+		executions, err = configs.ExecutionApplications.FindExecutionsByUserZCode(userZCode, from, to, limit)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	results := make([]*ExecutionResult, 0, len(executions))
+	for _, execution := range executions {
+		results = append(results, FromExecutionEntity(execution))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// LectureExecutionStatsHandler gives the lecturer per-student run counts, error ratio and last error.
+func LectureExecutionStatsHandler(c *gin.Context) {
+	lectureID, err := strconv.ParseUint(c.Param("lecture_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid lecture_id",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if !access.IsLecturer(uint(lectureID), zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the lecturer can view execution statistics",
+		})
+		return
+	}
+
+	stats, err := LectureExecutionStats(uint(lectureID), "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+func ListPoliciesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    GlobalPolicyRegistry.List(),
+	})
+}
+
+type SetClassPolicyRequest struct {
+	Policy string `json:"policy" binding:"required"`
+}
+
+// SetClassPolicyHandler lets the class manager choose the code policy for every lecture of the class.
+func SetClassPolicyHandler(c *gin.Context) {
+	classID, err := strconv.ParseUint(c.Param("class_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid class_id",
+		})
+		return
+	}
+	var req SetClassPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if !access.IsClassManager(uint(classID), zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the class manager can change the code policy",
+		})
+		return
+	}
+
+	if err := GlobalPolicyRegistry.SetClassPolicy(uint(classID), req.Policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    GlobalPolicyRegistry.PolicyForClass(uint(classID)),
+	})
+}
+
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// DownloadArtifactHandler serves a file the program wrote to /output. Query: path.
+func DownloadArtifactHandler(c *gin.Context) {
+	executionID := c.Param("id")
+	artifactPath := c.Query("path")
+	if executionID == "" || artifactPath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Execution ID and path are required",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	result, err := GlobalExecutionManager.GetResult(executionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Execution result not found",
+		})
+		return
+	}
+	// the teacher's artifacts are visible to the class, students' only to themselves and the teacher
+	if !canViewExecution(result, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You can not view this artifact",
+		})
+		return
+	}
+
+	artifact, err := GlobalExecutionManager.GetArtifact(executionID, artifactPath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(artifact.Path)))
+	c.Data(http.StatusOK, artifact.MIMEType, artifact.Content)
+}
+
+func ListLanguagesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    GlobalLanguageRegistry.List(),
+	})
+}
+
+type ExecutionProfileRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	ClassID         uint     `json:"class_id" binding:"required"`
+	LectureID       uint     `json:"lecture_id"` // 0 applies to every lecture of the class
+	Language        string   `json:"language"`
+	UserRole        string   `json:"user_role"`
+	TimeoutSeconds  int      `json:"timeout_seconds"`
+	MemoryLimitMB   int      `json:"memory_limit_mb"`
+	CPULimit        float64  `json:"cpu_limit"`
+	OutputLimitKB   int      `json:"output_limit_kb"`
+	AllowedPackages []string `json:"allowed_packages"`
+}
+
+func (r *ExecutionProfileRequest) toEntity(profile *entities.ExecutionProfile) {
+	packages := make([]string, 0, len(r.AllowedPackages))
+	for _, name := range r.AllowedPackages {
+		if name = strings.TrimSpace(name); name != "" {
+			packages = append(packages, name)
+		}
+	}
+	profile.Name = strings.TrimSpace(r.Name)
+	profile.ClassID = r.ClassID
+	profile.LectureID = r.LectureID
+	profile.Language = r.Language
+	profile.UserRole = strings.ToLower(strings.TrimSpace(r.UserRole))
+	profile.TimeoutSeconds = r.TimeoutSeconds
+	profile.MemoryLimitMB = r.MemoryLimitMB
+	profile.CPULimit = r.CPULimit
+	profile.OutputLimitKB = r.OutputLimitKB
+	profile.AllowedPackages = strings.Join(packages, ",")
+}
+
+// canManageProfile allows the class manager to manage every profile of the class
+// and a lecturer the profiles of their own lecture.
+func canManageProfile(profile *entities.ExecutionProfile, zcode uint64) bool {
+	if access.IsClassManager(profile.ClassID, zcode) {
+		return true
+	}
+	if profile.LectureID == 0 {
+		return false
+	}
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(profile.LectureID)
+	if err != nil {
+		return false
+	}
+	return lecture.ClassID == profile.ClassID && lecture.LecturerZCodeID == zcode
+}
+
+// checkProfileLecture makes sure a lecture profile is stored with the lecture's class.
+func checkProfileLecture(profile *entities.ExecutionProfile) error {
+	if profile.LectureID == 0 {
+		return nil
+	}
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(profile.LectureID)
+	if err != nil {
+		return err
+	}
+	if lecture.ClassID != profile.ClassID {
+		return fmt.Errorf("lecture %d does not belong to class %d", profile.LectureID, profile.ClassID)
+	}
+	return nil
+}
+
+// ListExecutionProfilesHandler lists the profiles of a class to its manager and lecturers. Query: class_id.
+func ListExecutionProfilesHandler(c *gin.Context) {
+	classID, err := strconv.ParseUint(c.Query("class_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid class_id",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	allowed := access.IsClassManager(uint(classID), zcode)
+	if !allowed {
+		lectures, _ := configs.ClassApplications.FindLecturesByClassID(uint(classID))
+		for _, lecture := range lectures {
+			if lecture.LecturerZCodeID == zcode {
+				allowed = true
+				break
+			}
+		}
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the class manager and lecturers can view execution profiles",
+		})
+		return
+	}
+
+	profiles, err := configs.ExecutionProfileApplications.FindExecutionProfilesByClassID(uint(classID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    profiles,
+	})
+}
+
+func CreateExecutionProfileHandler(c *gin.Context) {
+	var req ExecutionProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
+	profile := &entities.ExecutionProfile{CreatedByZCodeID: zcode}
+	req.toEntity(profile)
+	if err := ValidateProfile(profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := checkProfileLecture(profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !canManageProfile(profile, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the class manager or the lecturer can manage execution profiles",
+		})
+		return
+	}
+
+	if err := configs.ExecutionProfileApplications.CreateExecutionProfile(profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    profile,
+	})
+}
+
+func UpdateExecutionProfileHandler(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid profile id",
+		})
+		return
+	}
+	var req ExecutionProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
+	profile, err := configs.ExecutionProfileApplications.FindExecutionProfileByID(uint(profileID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	// the caller needs the right on the profile as it is and as it will be
+	if !canManageProfile(profile, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the class manager or the lecturer can manage execution profiles",
+		})
+		return
+	}
+	req.toEntity(profile)
+	if err := ValidateProfile(profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := checkProfileLecture(profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !canManageProfile(profile, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the class manager or the lecturer can manage execution profiles",
+		})
+		return
+	}
+
+	if err := configs.ExecutionProfileApplications.UpdateExecutionProfile(profile); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    profile,
+	})
+}
+
+func DeleteExecutionProfileHandler(c *gin.Context) {
+	profileID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid profile id",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
+	profile, err := configs.ExecutionProfileApplications.FindExecutionProfileByID(uint(profileID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !canManageProfile(profile, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the class manager or the lecturer can manage execution profiles",
+		})
+		return
+	}
+
+	if err := configs.ExecutionProfileApplications.DeleteExecutionProfile(profile.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    nil,
+	})
+}
+
+// ResolveExecutionProfileHandler shows the caller the limits their runs get. Query: lecture_id, language.
+func ResolveExecutionProfileHandler(c *gin.Context) {
+	lectureID, err := strconv.ParseUint(c.Query("lecture_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid lecture_id",
+		})
+		return
+	}
+	lang, err := GlobalLanguageRegistry.Get(c.DefaultQuery("language", "python"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	userRole, ok := access.ClassRole(uint(lectureID), zcode)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You are not a participant of this lecture's class",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ResolveProfile(lang, uint(lectureID), userRole),
+	})
+}
+
+type RuntimeImageRequest struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Language    string `json:"language"`
+	Image       string `json:"image"`
+	Description string `json:"description"`
+	Packages    string `json:"packages"`
+}
+
+func (r *RuntimeImageRequest) toEntity(runtime *entities.RuntimeImage) {
+	runtime.Name = strings.TrimSpace(r.Name)
+	runtime.Language = r.Language
+	runtime.Image = strings.TrimSpace(r.Image)
+	runtime.Description = r.Description
+	runtime.Packages = strings.Join(SplitAllowedPackages(r.Packages), ",")
+}
+
+// ListRuntimeImagesHandler lists the runtime images a class can choose from.
+func ListRuntimeImagesHandler(c *gin.Context) {
+	runtimes, err := configs.RuntimeImageApplications.FindAllRuntimeImages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runtimes,
+	})
+}
+
+// CreateRuntimeImageHandler is for admins, the route is guarded by CheckPermissions.
+func CreateRuntimeImageHandler(c *gin.Context) {
+	var req RuntimeImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
+	runtime := &entities.RuntimeImage{CreatedByZCodeID: zcode}
+	req.toEntity(runtime)
+	if err := ValidateRuntimeImage(runtime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := configs.RuntimeImageApplications.CreateRuntimeImage(runtime); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runtime,
+	})
+}
+
+// UpdateRuntimeImageHandler is for admins, the route is guarded by CheckPermissions.
+func UpdateRuntimeImageHandler(c *gin.Context) {
+	var req RuntimeImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	runtime, err := configs.RuntimeImageApplications.FindRuntimeImageByID(req.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if req.Language != runtime.Language {
+		// classes chose the image for its language
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "The language of a runtime image cannot change",
+		})
+		return
+	}
+	previousImage := runtime.Image
+	req.toEntity(runtime)
+	if err := ValidateRuntimeImage(runtime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := configs.RuntimeImageApplications.UpdateRuntimeImage(runtime); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	forgetPackageListing(previousImage)
+	forgetPackageListing(runtime.Image)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runtime,
+	})
+}
+
+// DeleteRuntimeImageHandler is for admins, the classes using the image go back to
+// the default one.
+func DeleteRuntimeImageHandler(c *gin.Context) {
+	var req RuntimeImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	runtime, err := configs.RuntimeImageApplications.FindRuntimeImageByID(req.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := configs.RuntimeImageApplications.DeleteRuntimeImage(runtime.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	forgetPackageListing(runtime.Image)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+type SetClassRuntimeRequest struct {
+	Language       string `json:"language" binding:"required"`
+	RuntimeImageID uint   `json:"runtime_image_id"` // 0 goes back to the default image
+}
+
+// ListClassRuntimesHandler lists the runtime images the class chose, per language.
+func ListClassRuntimesHandler(c *gin.Context) {
+	classID, err := strconv.ParseUint(c.Param("class_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid class_id",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if _, ok := access.ClassRoleByClassID(uint(classID), zcode); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You are not a participant of this class",
+		})
+		return
+	}
+
+	classRuntimes, err := configs.RuntimeImageApplications.FindClassRuntimesByClassID(uint(classID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    classRuntimes,
+	})
+}
+
+func SetClassRuntimeHandler(c *gin.Context) {
+	classID, err := strconv.ParseUint(c.Param("class_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid class_id",
+		})
+		return
+	}
+	var req SetClassRuntimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if !access.IsClassManager(uint(classID), zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the class manager can choose the runtime image",
+		})
+		return
+	}
+
+	if req.RuntimeImageID == 0 {
+		if err := configs.RuntimeImageApplications.DeleteClassRuntime(uint(classID), req.Language); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+		})
+		return
+	}
+
+	runtime, err := configs.RuntimeImageApplications.FindRuntimeImageByID(req.RuntimeImageID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if runtime.Language != req.Language {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Runtime image %s is for %s", runtime.Name, runtime.Language),
+		})
+		return
+	}
+
+	classRuntime := &entities.ClassRuntime{
+		ClassID:        uint(classID),
+		Language:       req.Language,
+		RuntimeImageID: runtime.ID,
+	}
+	if err := configs.RuntimeImageApplications.SaveClassRuntime(classRuntime); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    classRuntime,
+	})
+}
+
+// ListPackagesHandler tells a participant which packages their code can import in
+// the lecture. Query: lecture_id, language.
+func ListPackagesHandler(c *gin.Context) {
+	lectureID, err := strconv.ParseUint(c.Query("lecture_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid lecture_id",
+		})
+		return
+	}
+	lang, err := GlobalLanguageRegistry.Get(c.DefaultQuery("language", "python"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	userRole, ok := access.ClassRole(uint(lectureID), zcode)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You are not a participant of this lecture's class",
+		})
+		return
+	}
+
+	listing, err := ListPackages(lang, uint(lectureID), userRole)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    listing,
+	})
+}
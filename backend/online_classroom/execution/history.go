@@ -0,0 +1,168 @@
+package execution
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"github.com/goccy/go-json"
+	"log"
+	"time"
+)
+
+// ExecutionRetention is how long finished executions stay in the executions table.
+var ExecutionRetention = 30 * 24 * time.Hour
+
+const retentionSweepInterval = time.Hour
+
+// persistResult stores a finished execution with its artifacts and reports whether
+// it did. The application layer is wired in configs.InitALl, so results finished
+// before that are only kept in memory.
+func persistResult(result *ExecutionResult) bool {
+	if configs.ExecutionApplications == nil {
+		return false
+	}
+	artifacts := make([]*entities.ExecutionArtifact, 0, len(result.Artifacts))
+	for _, artifact := range result.Artifacts {
+		artifacts = append(artifacts, &entities.ExecutionArtifact{
+			Path:     artifact.Path,
+			MIMEType: artifact.MIMEType,
+			Size:     artifact.Size,
+			Content:  artifact.Content,
+		})
+	}
+	if err := configs.ExecutionApplications.SaveExecution(toExecutionEntity(result), artifacts); err != nil {
+		log.Printf("Failed to persist execution %s: %v", result.ID, err)
+		return false
+	}
+	return true
+}
+
+// loadResult reads an execution that has already been evicted from the cache.
+func loadResult(id string) (*ExecutionResult, error) {
+	if configs.ExecutionApplications == nil {
+		return nil, errResultNotFound
+	}
+	execution, err := configs.ExecutionApplications.FindExecutionByExecutionID(id)
+	if err != nil {
+		return nil, errResultNotFound
+	}
+	result := FromExecutionEntity(execution)
+	if artifacts, err := configs.ExecutionApplications.FindArtifactsByExecutionID(id); err == nil {
+		for _, artifact := range artifacts {
+			result.Artifacts = append(result.Artifacts, Artifact{
+				Path:     artifact.Path,
+				MIMEType: artifact.MIMEType,
+				Size:     artifact.Size,
+			})
+		}
+	}
+	return result, nil
+}
+
+func loadArtifact(id string, artifactPath string) (*Artifact, error) {
+	if configs.ExecutionApplications == nil {
+		return nil, errArtifactNotFound
+	}
+	artifact, err := configs.ExecutionApplications.FindArtifact(id, artifactPath)
+	if err != nil {
+		return nil, errArtifactNotFound
+	}
+	return &Artifact{
+		Path:     artifact.Path,
+		MIMEType: artifact.MIMEType,
+		Size:     artifact.Size,
+		Content:  artifact.Content,
+	}, nil
+}
+
+func retentionJanitor() {
+	ticker := time.NewTicker(retentionSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if configs.ExecutionApplications == nil {
+			continue
+		}
+		deleted, err := configs.ExecutionApplications.PurgeExecutionsOlderThan(ExecutionRetention)
+		if err != nil {
+			log.Printf("Failed to purge old executions: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Purged %d executions older than %v", deleted, ExecutionRetention)
+		}
+	}
+}
+
+func toExecutionEntity(result *ExecutionResult) *entities.Execution {
+	execution := &entities.Execution{
+		ExecutionID:       result.ID,
+		LectureID:         result.LectureID,
+		UserZCode:         result.UserZCode,
+		Language:          result.Language,
+		Code:              result.Code,
+		Status:            result.Status,
+		Output:            result.Output,
+		Error:             result.Error,
+		ExitCode:          result.ExitCode,
+		StdinExhausted:    result.StdinExhausted,
+		CompileStatus:     result.CompileStatus,
+		CompileOutput:     result.CompileOutput,
+		CompileDurationMS: result.CompileDurationMS,
+		DurationMS:        result.DurationMS,
+		PeakMemoryBytes:   result.PeakMemoryBytes,
+		CPUTimeMS:         result.CPUTimeMS,
+		OOMKilled:         result.OOMKilled,
+		TimedOut:          result.TimedOut,
+		OutputTruncated:   result.OutputTruncated,
+		OutputBytes:       result.OutputBytes,
+	}
+	if len(result.Diagnostics) > 0 {
+		if diagnostics, err := json.Marshal(result.Diagnostics); err == nil {
+			execution.Diagnostics = string(diagnostics)
+		}
+	}
+	if !result.QueuedAt.IsZero() {
+		queuedAt := result.QueuedAt
+		execution.QueuedAt = &queuedAt
+	}
+	if !result.ExecutedAt.IsZero() {
+		executedAt := result.ExecutedAt
+		execution.ExecutedAt = &executedAt
+	}
+	return execution
+}
+
+func FromExecutionEntity(execution *entities.Execution) *ExecutionResult {
+	result := &ExecutionResult{
+		ID:                execution.ExecutionID,
+		LectureID:         execution.LectureID,
+		UserZCode:         execution.UserZCode,
+		Code:              execution.Code,
+		Language:          execution.Language,
+		Output:            execution.Output,
+		Error:             execution.Error,
+		ExitCode:          execution.ExitCode,
+		DurationMS:        execution.DurationMS,
+		Status:            execution.Status,
+		StdinExhausted:    execution.StdinExhausted,
+		CompileStatus:     execution.CompileStatus,
+		CompileOutput:     execution.CompileOutput,
+		CompileDurationMS: execution.CompileDurationMS,
+		PeakMemoryBytes:   execution.PeakMemoryBytes,
+		CPUTimeMS:         execution.CPUTimeMS,
+		OOMKilled:         execution.OOMKilled,
+		TimedOut:          execution.TimedOut,
+		OutputTruncated:   execution.OutputTruncated,
+		OutputBytes:       execution.OutputBytes,
+	}
+	if execution.Diagnostics != "" {
+		json.Unmarshal([]byte(execution.Diagnostics), &result.Diagnostics)
+	}
+	if execution.QueuedAt != nil {
+		result.QueuedAt = *execution.QueuedAt
+	}
+	if execution.ExecutedAt != nil {
+		result.ExecutedAt = *execution.ExecutedAt
+	}
+	return result
+}
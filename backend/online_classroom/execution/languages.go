@@ -0,0 +1,227 @@
+package execution
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type LanguageSpec struct {
+	Name          string          `json:"name"`
+	DisplayName   string          `json:"display_name"`
+	Version       string          `json:"version"`
+	Image         string          `json:"image"`
+	SourceFile    string          `json:"source_file"`
+	CompileCmd    []string        `json:"compile_cmd,omitempty"` // run by sh, so globs and $(...) work
+	RunCmd        []string        `json:"run_cmd"`
+	ReplCmd       []string        `json:"repl_cmd,omitempty"` // interactive interpreter, empty when the language has none
+	Env           []string        `json:"-"`
+	DefaultConfig ExecutionConfig `json:"default_config"`
+
+	// stderr fragments the runtime prints when the program reads past the end of stdin
+	EOFPatterns []string `json:"-"`
+
+	// Validate applies the language specific rules of a code policy to the workspace
+	Validate func(files []WorkspaceFile, policy *CodePolicy) []PolicyViolation `json:"-"`
+	// InputIncomplete reports whether a line typed into the interpreter ends inside a statement
+	InputIncomplete func(line string) bool `json:"-"`
+
+	// PackageListCode prints one "name==version" line per package installed in the image
+	PackageListCode string `json:"-"`
+}
+
+func (l *LanguageSpec) IsCompiled() bool {
+	return len(l.CompileCmd) > 0
+}
+
+// withImage is a copy of the language that runs in another image.
+func (l *LanguageSpec) withImage(image string) *LanguageSpec {
+	if image == "" || image == l.Image {
+		return l
+	}
+	spec := *l
+	spec.Image = image
+	return &spec
+}
+
+// ConfigForRole returns the limits for a run. Teachers get TeacherExecutionConfig,
+// but never less than the language defaults (compilers need more room than scripts).
+func (l *LanguageSpec) ConfigForRole(userRole string) ExecutionConfig {
+	if userRole != "teacher" {
+		return l.DefaultConfig
+	}
+
+	config := TeacherExecutionConfig
+	if l.DefaultConfig.TimeoutSeconds > config.TimeoutSeconds {
+		config.TimeoutSeconds = l.DefaultConfig.TimeoutSeconds
+	}
+	if l.DefaultConfig.CompileTimeoutSeconds > config.CompileTimeoutSeconds {
+		config.CompileTimeoutSeconds = l.DefaultConfig.CompileTimeoutSeconds
+	}
+	if l.DefaultConfig.MemoryLimitMB > config.MemoryLimitMB {
+		config.MemoryLimitMB = l.DefaultConfig.MemoryLimitMB
+	}
+	if l.DefaultConfig.CPULimit > config.CPULimit {
+		config.CPULimit = l.DefaultConfig.CPULimit
+	}
+	if l.DefaultConfig.ArtifactLimitMB > config.ArtifactLimitMB {
+		config.ArtifactLimitMB = l.DefaultConfig.ArtifactLimitMB
+	}
+	if l.DefaultConfig.OutputLimitKB > config.OutputLimitKB {
+		config.OutputLimitKB = l.DefaultConfig.OutputLimitKB
+	}
+	return config
+}
+
+type LanguageRegistry struct {
+	languages map[string]*LanguageSpec
+	mutex     sync.RWMutex
+}
+
+func NewLanguageRegistry() *LanguageRegistry {
+	return &LanguageRegistry{
+		languages: make(map[string]*LanguageSpec),
+	}
+}
+
+var GlobalLanguageRegistry = NewLanguageRegistry()
+
+func (lr *LanguageRegistry) Register(spec *LanguageSpec) {
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+	lr.languages[spec.Name] = spec
+}
+
+func (lr *LanguageRegistry) Get(name string) (*LanguageSpec, error) {
+	lr.mutex.RLock()
+	defer lr.mutex.RUnlock()
+
+	if spec, exists := lr.languages[name]; exists {
+		return spec, nil
+	}
+	return nil, fmt.Errorf("unsupported language: %s", name)
+}
+
+func (lr *LanguageRegistry) List() []*LanguageSpec {
+	lr.mutex.RLock()
+	defer lr.mutex.RUnlock()
+
+	specs := make([]*LanguageSpec, 0, len(lr.languages))
+	for _, spec := range lr.languages {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+func init() {
+	GlobalLanguageRegistry.Register(&LanguageSpec{
+		Name:          "python",
+		DisplayName:   "Python",
+		Version:       "3.11",
+		Image:         "python:3.11-alpine",
+		SourceFile:    "main.py",
+		RunCmd:        []string{"python", "{entry}"},
+		ReplCmd:       []string{"python", "-u", "-i", "-q"},
+		DefaultConfig: DefaultExecutionConfig,
+		Validate:      checkPythonWorkspace,
+		EOFPatterns:   []string{"EOFError: EOF when reading a line"},
+		PackageListCode: `import importlib.metadata as metadata
+for dist in metadata.distributions():
+    print(dist.metadata["Name"] + "==" + dist.version)
+`,
+		InputIncomplete: pythonInputIncomplete,
+	})
+
+	GlobalLanguageRegistry.Register(&LanguageSpec{
+		Name:          "javascript",
+		DisplayName:   "JavaScript (Node.js)",
+		Version:       "20",
+		Image:         "node:20-alpine",
+		SourceFile:    "main.js",
+		RunCmd:        []string{"node", "{entry}"},
+		ReplCmd:       []string{"node", "-i"},
+		DefaultConfig: DefaultExecutionConfig,
+		EOFPatterns:   []string{"ERR_USE_AFTER_CLOSE", "EOF: end of file"},
+		PackageListCode: `const fs = require("fs");
+const path = require("path");
+function list(dir) {
+  for (const name of fs.readdirSync(dir)) {
+    const full = path.join(dir, name);
+    if (name.startsWith("@")) {
+      list(full);
+      continue;
+    }
+    try {
+      const pkg = JSON.parse(fs.readFileSync(path.join(full, "package.json"), "utf8"));
+      console.log(pkg.name + "==" + pkg.version);
+    } catch (e) {}
+  }
+}
+for (const root of ["/usr/local/lib/node_modules", "/usr/lib/node_modules"]) {
+  if (fs.existsSync(root)) list(root);
+}
+`,
+	})
+
+	GlobalLanguageRegistry.Register(&LanguageSpec{
+		Name:        "java",
+		DisplayName: "Java",
+		Version:     "17",
+		Image:       "eclipse-temurin:17-jdk-alpine",
+		SourceFile:  "Main.java",
+		CompileCmd:  []string{"javac", "-d", "/tmp/build", "$(find /app -name '*.java')"},
+		RunCmd:      []string{"java", "-cp", "/tmp/build:/app", "{main_class}"},
+		EOFPatterns: []string{"java.util.NoSuchElementException"},
+		DefaultConfig: ExecutionConfig{
+			TimeoutSeconds:        10,
+			CompileTimeoutSeconds: 30,
+			MemoryLimitMB:         384,
+			CPULimit:              1.0,
+			NetworkAccess:         false,
+			ArtifactLimitMB:       8,
+			OutputLimitKB:         256,
+		},
+	})
+
+	GlobalLanguageRegistry.Register(&LanguageSpec{
+		Name:        "c",
+		DisplayName: "C (GCC)",
+		Version:     "13",
+		Image:       "gcc:13",
+		SourceFile:  "main.c",
+		CompileCmd:  []string{"gcc", "-O2", "-Wall", "-std=c17", "-I/app", "-o", "/tmp/build/main", "$(find /app -name '*.c')", "-lm"},
+		RunCmd:      []string{"/tmp/build/main"},
+		DefaultConfig: ExecutionConfig{
+			TimeoutSeconds:        10,
+			CompileTimeoutSeconds: 20,
+			MemoryLimitMB:         256,
+			CPULimit:              0.5,
+			NetworkAccess:         false,
+			ArtifactLimitMB:       8,
+			OutputLimitKB:         256,
+		},
+	})
+
+	GlobalLanguageRegistry.Register(&LanguageSpec{
+		Name:        "go",
+		DisplayName: "Go",
+		Version:     "1.23",
+		Image:       "golang:1.23-alpine",
+		SourceFile:  "main.go",
+		CompileCmd:  []string{"go", "build", "-o", "/tmp/build/main", "{entry_dir}/*.go"},
+		RunCmd:      []string{"/tmp/build/main"},
+		Env:         []string{"HOME=/tmp", "GOCACHE=/tmp/.cache", "GOPATH=/tmp/go", "CGO_ENABLED=0"},
+		DefaultConfig: ExecutionConfig{
+			TimeoutSeconds:        10,
+			CompileTimeoutSeconds: 45,
+			MemoryLimitMB:         512,
+			CPULimit:              1.0,
+			NetworkAccess:         false,
+			ArtifactLimitMB:       8,
+			OutputLimitKB:         256,
+		},
+	})
+}
@@ -0,0 +1,362 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// localRuntime is how the local backend runs a language with the host's interpreter.
+type localRuntime struct {
+	// Cmd takes the RunCmd placeholders and {memory_mb}
+	Cmd []string
+
+	// ReplCmd is the interactive interpreter, it takes {memory_mb}
+	ReplCmd []string
+
+	// runtimes such as V8 reserve far more address space than they use, for
+	// them the memory limit is a flag in Cmd instead of RLIMIT_AS
+	UnlimitedAddressSpace bool
+
+	// stderr fragments printed when an allocation fails
+	OOMPatterns []string
+}
+
+var localRuntimes = map[string]localRuntime{
+	"python": {
+		// -B: no .pyc files next to the sources, -E -s: no PYTHON* variables, no user site
+		Cmd:         []string{"python3", "-B", "-E", "-s", "{entry}"},
+		ReplCmd:     []string{"python3", "-B", "-E", "-s", "-u", "-i", "-q"},
+		OOMPatterns: []string{"MemoryError"},
+	},
+	"javascript": {
+		Cmd:                   []string{"node", "--max-old-space-size={memory_mb}", "{entry}"},
+		ReplCmd:               []string{"node", "--max-old-space-size={memory_mb}", "-i"},
+		UnlimitedAddressSpace: true,
+		OOMPatterns:           []string{"JavaScript heap out of memory"},
+	},
+}
+
+// localPath is the only PATH a local program sees.
+const localPath = "/usr/local/bin:/usr/bin:/bin"
+
+// LocalExecutor runs interpreters as child processes of the backend, for dev
+// machines and CI without Docker. It is not a security boundary like the
+// containers: the program runs as the backend's user and can read what that
+// user can read. Limits come from rlimits set by a re-executed copy of the
+// backend binary (see runSandboxHelper) just before it execs the interpreter.
+type LocalExecutor struct {
+	// MaxProcesses is how many processes and threads a program may add to the
+	// ones the backend's user already runs
+	MaxProcesses int
+}
+
+func NewLocalExecutor() *LocalExecutor {
+	return &LocalExecutor{MaxProcesses: 64}
+}
+
+func (le *LocalExecutor) Execute(task *ExecutionTask) (*ExecutionResult, error) {
+	startTime := time.Now()
+	lang := task.Language
+
+	result, ok := beginExecution(task, startTime)
+	if !ok {
+		return result, nil
+	}
+
+	runtime, supported := localRuntimes[lang.Name]
+	if !supported {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("%s is not available on the local execution backend", lang.DisplayName)
+		result.DurationMS = time.Since(startTime).Milliseconds()
+		return result, nil
+	}
+
+	dir, err := le.createSandboxDir(task)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("Failed to create temp file: %v", err)
+		result.DurationMS = time.Since(startTime).Milliseconds()
+		return result, nil
+	}
+	defer os.RemoveAll(dir)
+
+	le.run(dir, runtime, task, result)
+	if result.Status != "cancelled" && task.Config.ArtifactLimitMB > 0 {
+		artifacts, err := collectLocalArtifacts(filepath.Join(dir, "output"), task.Config.ArtifactLimitMB)
+		if err != nil {
+			log.Printf("WARNING: failed to collect artifacts of %s: %v", result.ID, err)
+		}
+		result.Artifacts = artifacts
+	}
+	result.DurationMS = time.Since(startTime).Milliseconds()
+
+	return result, nil
+}
+
+// createSandboxDir lays out a private directory: the workspace in app, scratch
+// space in tmp and the artifact directory in output.
+func (le *LocalExecutor) createSandboxDir(task *ExecutionTask) (string, error) {
+	dir, err := os.MkdirTemp("", "zcode_local_")
+	if err != nil {
+		return "", err
+	}
+	for _, sub := range []string{"app", "tmp", "output"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0700); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	if err := writeWorkspace(filepath.Join(dir, "app"), task.Files); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+func (le *LocalExecutor) run(dir string, runtime localRuntime, task *ExecutionTask, result *ExecutionResult) {
+	lang := task.Language
+	config := task.Config
+
+	self, err := os.Executable()
+	if err != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("Failed to start sandbox: %v", err)
+		return
+	}
+
+	appDir := filepath.Join(dir, "app")
+	command := withMemoryLimit(expandCommandAt(runtime.Cmd, appDir, task.Entrypoint), config)
+	limits := le.limits(runtime, config, config.TimeoutSeconds)
+
+	runCtx, runCancel := context.WithTimeout(task.context(), time.Duration(config.TimeoutSeconds)*time.Second)
+	defer runCancel()
+	cmd := exec.CommandContext(runCtx, self, append(limits.args(), command...)...)
+	cmd.Dir = appDir
+	cmd.Env = localEnv(dir, config)
+	cmd.Stdin = strings.NewReader(task.Stdin)
+	// a process group, so a timeout also kills whatever the program started
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+
+	budget := newOutputBudget(config.OutputLimitKB, func() {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	})
+	stdout := newLimitedStreamWriter("stdout", task.OnOutput, budget)
+	stderr := newLimitedStreamWriter("stderr", task.OnOutput, budget)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	if cmd.Process != nil {
+		// background processes left behind by the program
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	result.Output = joinOutput(stdout.String(), stderr.String())
+	result.Stdout = stdout.String()
+	result.OutputBytes = int64(len(result.Output))
+	if budget != nil {
+		result.OutputBytes = budget.Printed()
+	}
+	signal := syscall.Signal(0)
+	if state := cmd.ProcessState; state != nil {
+		result.ExitCode = state.ExitCode()
+		if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+			result.PeakMemoryBytes = usage.Maxrss * 1024 // kilobytes on Linux
+			result.CPUTimeMS = (time.Duration(usage.Utime.Nano()) + time.Duration(usage.Stime.Nano())).Milliseconds()
+		}
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			signal = status.Signal()
+			result.ExitCode = 128 + int(signal) // what a shell or docker reports
+		}
+	} else {
+		result.ExitCode = -1
+	}
+
+	defer markStdinExhausted(result, lang)
+	if task.context().Err() != nil {
+		result.Status = "cancelled"
+		result.ExitCode = -1
+		result.Error = ErrExecutionCancelled.Error()
+		return
+	}
+	if budget.Exceeded() {
+		result.Status = "output_limit"
+		result.OutputTruncated = true
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("program was stopped after printing more than %d KB", config.OutputLimitKB)
+		return
+	}
+	if runCtx.Err() == context.DeadlineExceeded || signal == syscall.SIGXCPU {
+		result.Status = "timeout"
+		result.TimedOut = true
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("execution timeout after %d seconds", config.TimeoutSeconds)
+		return
+	}
+	for _, pattern := range runtime.OOMPatterns {
+		if err != nil && strings.Contains(stderr.String(), pattern) {
+			result.Status = "memory_exceeded"
+			result.OOMKilled = true
+			result.Error = fmt.Sprintf("program was killed after exceeding the memory limit of %d MB", config.MemoryLimitMB)
+			return
+		}
+	}
+	if signal == syscall.SIGXFSZ {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("program wrote a file larger than %d MB", limits.fileSizeBytes/1024/1024)
+		return
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			result.Error = fmt.Sprintf("Failed to start sandbox: %v", err)
+		}
+		return
+	}
+	result.Status = "completed"
+}
+
+// startInteractive runs the interpreter with the limits of a run, except that
+// the CPU time covers the whole session.
+func (le *LocalExecutor) startInteractive(name string, lang *LanguageSpec, config ExecutionConfig, stdout io.Writer, stderr io.Writer) (*interactiveProcess, error) {
+	runtime, supported := localRuntimes[lang.Name]
+	if !supported || len(runtime.ReplCmd) == 0 {
+		return nil, ErrReplUnsupported
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := le.createSandboxDir(&ExecutionTask{})
+	if err != nil {
+		return nil, err
+	}
+
+	limits := le.limits(runtime, config, int(ReplMaxLifetime.Seconds()))
+	cmd := exec.Command(self, append(limits.args(), withMemoryLimit(runtime.ReplCmd, config)...)...)
+	cmd.Dir = filepath.Join(dir, "app")
+	cmd.Env = localEnv(dir, config)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &interactiveProcess{
+		Stdin: stdin,
+		Wait: func() error {
+			defer os.RemoveAll(dir)
+			return cmd.Wait()
+		},
+		Stop: func() { syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) },
+	}, nil
+}
+
+func (le *LocalExecutor) limits(runtime localRuntime, config ExecutionConfig, cpuSeconds int) sandboxLimits {
+	limits := sandboxLimits{
+		cpuSeconds: cpuSeconds,
+		// the container's /tmp holds 16 MB, locally tmp and output share this per-file limit
+		fileSizeBytes: int64(max(config.ArtifactLimitMB, 16)) * 1024 * 1024,
+		processes:     le.MaxProcesses,
+	}
+	if !runtime.UnlimitedAddressSpace {
+		limits.addressSpaceBytes = int64(config.MemoryLimitMB) * 1024 * 1024
+	}
+	return limits
+}
+
+func withMemoryLimit(command []string, config ExecutionConfig) []string {
+	expanded := make([]string, len(command))
+	for i, arg := range command {
+		expanded[i] = strings.ReplaceAll(arg, "{memory_mb}", strconv.Itoa(config.MemoryLimitMB))
+	}
+	return expanded
+}
+
+// localEnv is the whole environment of a local program: nothing of the
+// backend's own environment (database passwords, tokens) is passed on.
+func localEnv(dir string, config ExecutionConfig) []string {
+	tmpDir := filepath.Join(dir, "tmp")
+	env := []string{
+		"PATH=" + localPath,
+		"HOME=" + tmpDir,
+		"TMPDIR=" + tmpDir,
+		"LANG=C.UTF-8",
+	}
+	if config.ArtifactLimitMB > 0 {
+		env = append(env, "OUTPUT_DIR="+filepath.Join(dir, "output"), "MPLBACKEND=Agg", "MPLCONFIGDIR="+filepath.Join(tmpDir, "matplotlib"))
+	}
+	return env
+}
+
+// collectLocalArtifacts reads the output directory with the rules of
+// readArtifactArchive: regular, non-hidden files within the limit.
+func collectLocalArtifacts(outputDir string, limitMB int) ([]Artifact, error) {
+	artifacts := make([]Artifact, 0)
+	limitBytes := int64(limitMB) * 1024 * 1024
+	var total int64
+	err := filepath.WalkDir(outputDir, func(filePath string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(outputDir, filePath)
+		if name != "." && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil // hidden files such as caches
+		}
+		if !entry.Type().IsRegular() {
+			return nil // directories, links and devices are never returned
+		}
+		if len(artifacts) >= MaxArtifacts {
+			return filepath.SkipAll
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if total+info.Size() > limitBytes {
+			return filepath.SkipAll
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		name = filepath.ToSlash(name)
+		artifacts = append(artifacts, Artifact{
+			Path:     name,
+			MIMEType: detectMIMEType(name, content),
+			Size:     info.Size(),
+			Content:  content,
+		})
+		return nil
+	})
+	return artifacts, err
+}
@@ -1,6 +1,8 @@
 package execution
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -9,77 +11,411 @@ import (
 
 type ExecutionManager struct {
 	executor Executor
-	results  map[string]*ExecutionResult
+	queue    *ExecutionQueue
+	streamer ExecutionStreamer
+	registry RunRegistry // nil on a single node
+	results  *resultCache
+	done     map[string]chan struct{}
+	cancels  map[string]context.CancelFunc
 	mutex    sync.RWMutex
 }
 
 var GlobalExecutionManager *ExecutionManager
 
-func init() {
-	executor := NewPythonExecutor()
+// ResultCacheSize bounds how many finished results are kept in memory.
+const ResultCacheSize = 1000
 
-	if err := executor.CheckDockerAvailable(); err != nil {
-		log.Printf("WARNING: Docker environment check failed: %v", err)
-		log.Printf("Python code execution may not work properly")
-	} else {
-		log.Printf("Docker environment check passed")
-	}
+var errResultNotFound = errors.New("execution result not found")
+var errArtifactNotFound = errors.New("artifact not found")
 
+const (
+	// how long a cancel waits for the node running the execution
+	remoteCancelTimeout = 15 * time.Second
+	remoteCancelPoll    = 200 * time.Millisecond
+)
+
+func init() {
 	GlobalExecutionManager = &ExecutionManager{
-		executor: executor,
-		results:  make(map[string]*ExecutionResult),
+		executor: newConfiguredExecutor(),
+		queue:    NewExecutionQueue(configuredQueueConfig()),
+		results:  newResultCache(ResultCacheSize),
+		done:     make(map[string]chan struct{}),
+		cancels:  make(map[string]context.CancelFunc),
 	}
+	GlobalReplManager = newReplManager(GlobalExecutionManager.executor)
+	go retentionJanitor()
 }
 
+func (em *ExecutionManager) SetStreamer(streamer ExecutionStreamer) {
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+	em.streamer = streamer
+}
+
+// ExecuteCode runs the code and blocks until it has finished. Grading runs are
+// dropped from the cache once their result is returned, their code holds the
+// hidden test code and nobody else looks them up.
 func (em *ExecutionManager) ExecuteCode(req ExecutionRequest, userRole string) (*ExecutionResult, error) {
-	var config ExecutionConfig
-	if userRole == "teacher" {
-		config = TeacherExecutionConfig
-	} else {
-		config = DefaultExecutionConfig
+	result, err := em.StartExecution(req, userRole)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Grading {
+		return em.WaitResult(result.ID)
+	}
+
+	em.mutex.RLock()
+	done, running := em.done[result.ID]
+	em.mutex.RUnlock()
+	if running {
+		<-done
+	}
+
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+	finished, exists := em.results.get(result.ID)
+	if !exists {
+		return nil, errResultNotFound
+	}
+	em.results.remove(result.ID)
+	snapshot := *finished
+	return &snapshot, nil
+}
+
+// StartExecution returns as soon as the execution is queued; output is pushed
+// through the streamer and the final result can be polled with GetResult.
+func (em *ExecutionManager) StartExecution(req ExecutionRequest, userRole string) (*ExecutionResult, error) {
+	lang, err := GlobalLanguageRegistry.Get(req.Language)
+	if err != nil {
+		return nil, err
 	}
+	profile := ResolveProfile(lang, req.LectureID, userRole)
+
+	if len(req.Stdin) > MaxStdinBytes {
+		return nil, fmt.Errorf("stdin too long (max %d KB)", MaxStdinBytes/1024)
+	}
+	files, entrypoint, err := NormalizeWorkspace(req, lang)
+	if err != nil {
+		return nil, err
+	}
+	code := workspaceEntryCode(files, entrypoint)
 
 	result := &ExecutionResult{
-		ID:         generateExecutionID(),
-		LectureID:  req.LectureID,
-		UserZCode:  req.UserZCode,
-		Code:       req.Code,
-		Language:   req.Language,
-		Status:     "running",
-		ExecutedAt: time.Now(),
+		ID:        generateExecutionID(),
+		LectureID: req.LectureID,
+		UserZCode: req.UserZCode,
+		Code:      code,
+		Language:  req.Language,
+		Status:    "queued",
+		QueuedAt:  time.Now(),
+		grading:   req.Grading,
+	}
+
+	em.mutex.RLock()
+	registry := em.registry
+	em.mutex.RUnlock()
+	if registry != nil && !req.Grading {
+		// the queue's own limit only sees the runs of this node
+		if err := registry.Admit(result, em.queue.config.MaxQueuedPerUser+em.queue.config.PerUserLimit); err != nil {
+			return nil, err
+		}
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	em.mutex.Lock()
-	em.results[result.ID] = result
+	em.results.put(result)
+	em.done[result.ID] = make(chan struct{})
+	em.cancels[result.ID] = cancel
+	snapshot := *result
 	em.mutex.Unlock()
 
-	execResult, err := em.executor.Execute(req.Code, config)
+	task := &ExecutionTask{
+		Code:       code,
+		Files:      files,
+		Entrypoint: entrypoint,
+		Stdin:      req.Stdin,
+		Language:   profile.Language,
+		Config:     profile.Config,
+		Policy:     profile.Policy,
+		Context:    ctx,
+	}
+	if !req.Grading {
+		task.OnOutput = em.outputPublisher(&snapshot)
+	}
+	err = em.queue.Enqueue(result.ID, req.LectureID, req.UserZCode, func() {
+		em.run(result, task)
+	})
+	if err != nil {
+		em.mutex.Lock()
+		close(em.done[result.ID])
+		delete(em.done, result.ID)
+		delete(em.cancels, result.ID)
+		em.results.remove(result.ID)
+		em.mutex.Unlock()
+		cancel()
+		if registry != nil && !req.Grading {
+			registry.Finish(result)
+		}
+		return nil, err
+	}
 
+	snapshot.QueuePosition = em.queue.Position(result.ID)
+	return &snapshot, nil
+}
+
+func (em *ExecutionManager) run(result *ExecutionResult, task *ExecutionTask) {
 	em.mutex.Lock()
-	defer em.mutex.Unlock()
+	if result.Status != "queued" {
+		// cancelled while waiting in the queue
+		em.mutex.Unlock()
+		return
+	}
+	result.Status = "running"
+	result.ExecutedAt = time.Now()
+	snapshot := *result
+	registry := em.registry
+	em.mutex.Unlock()
+	if registry != nil && !result.grading {
+		registry.Update(&snapshot)
+	}
 
-	if err != nil {
+	execResult, err := em.executor.Execute(task)
+
+	em.mutex.Lock()
+	if task.Context.Err() != nil {
+		result.Status = "cancelled"
+		result.Error = ErrExecutionCancelled.Error()
+		if execResult != nil {
+			result.Output = execResult.Output
+			result.Stdout = execResult.Stdout
+		}
+	} else if err != nil {
 		result.Status = "failed"
 		result.Error = err.Error()
 	} else {
 		result.Status = execResult.Status
 		result.Output = execResult.Output
+		result.Stdout = execResult.Stdout
+		result.StdinExhausted = execResult.StdinExhausted
 		result.Error = execResult.Error
 		result.ExitCode = execResult.ExitCode
+		result.CompileStatus = execResult.CompileStatus
+		result.CompileOutput = execResult.CompileOutput
+		result.CompileDurationMS = execResult.CompileDurationMS
+		result.Diagnostics = execResult.Diagnostics
+		result.Violations = execResult.Violations
+		result.Artifacts = execResult.Artifacts
+		result.PeakMemoryBytes = execResult.PeakMemoryBytes
+		result.CPUTimeMS = execResult.CPUTimeMS
+		result.OOMKilled = execResult.OOMKilled
+		result.TimedOut = execResult.TimedOut
+		result.OutputTruncated = execResult.OutputTruncated
+		result.OutputBytes = execResult.OutputBytes
 	}
 
 	result.DurationMS = time.Since(result.ExecutedAt).Milliseconds()
+	em.finishLocked(result)
+}
+
+// finishLocked releases waiters and publishes the final result. Caller holds the
+// mutex; it is released before publishing.
+func (em *ExecutionManager) finishLocked(result *ExecutionResult) {
+	finished := *result
+	streamer := em.streamer
+	registry := em.registry
+	cancel := em.cancels[result.ID]
+	close(em.done[result.ID])
+	delete(em.done, result.ID)
+	delete(em.cancels, result.ID)
+	if !result.grading {
+		// grading runs are removed by ExecuteCode, they must not push out other results
+		em.results.markFinished(result.ID)
+	}
+	em.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if finished.grading {
+		return
+	}
+	// persist first so statistics pushed alongside the finished message include this run
+	persisted := persistResult(&finished)
+	if registry != nil {
+		// other nodes read the result from the database from now on
+		registry.Finish(&finished)
+	}
+	if streamer != nil {
+		streamer.PublishFinished(&finished)
+	}
+
+	if persisted && len(finished.Artifacts) > 0 {
+		// artifact content is served from the database from now on, keep the cache small
+		em.mutex.Lock()
+		metadata := make([]Artifact, len(result.Artifacts))
+		for i, artifact := range result.Artifacts {
+			metadata[i] = Artifact{Path: artifact.Path, MIMEType: artifact.MIMEType, Size: artifact.Size}
+		}
+		result.Artifacts = metadata
+		em.mutex.Unlock()
+	}
+}
+
+// GetArtifact returns one artifact of a finished execution including its content.
+func (em *ExecutionManager) GetArtifact(id string, artifactPath string) (*Artifact, error) {
+	em.mutex.RLock()
+	if result, exists := em.results.get(id); exists {
+		for _, artifact := range result.Artifacts {
+			if artifact.Path == artifactPath && artifact.Content != nil {
+				found := artifact
+				em.mutex.RUnlock()
+				return &found, nil
+			}
+		}
+	}
+	em.mutex.RUnlock()
+
+	return loadArtifact(id, artifactPath)
+}
+
+// CancelExecution stops a queued or running execution. Queued runs are dropped
+// straight away; running ones have their container killed and finish as "cancelled".
+func (em *ExecutionManager) CancelExecution(id string) (*ExecutionResult, error) {
+	em.mutex.Lock()
+	result, exists := em.results.get(id)
+	if !exists {
+		registry := em.registry
+		streamer := em.streamer
+		em.mutex.Unlock()
+		if registry != nil {
+			if remote, _, running := registry.Get(id); running {
+				return em.cancelRemote(remote, registry, streamer)
+			}
+		}
+		if _, err := loadResult(id); err == nil {
+			return nil, fmt.Errorf("execution already finished")
+		}
+		return nil, errResultNotFound
+	}
 
-	return result, nil
+	switch result.Status {
+	case "queued":
+		em.queue.Remove(id)
+		result.Status = "cancelled"
+		result.Error = ErrExecutionCancelled.Error()
+		em.finishLocked(result)
+	case "running":
+		cancel := em.cancels[id]
+		em.mutex.Unlock()
+		if cancel != nil {
+			cancel()
+		}
+		return em.WaitResult(id)
+	default:
+		em.mutex.Unlock()
+		return nil, fmt.Errorf("execution already finished with status %s", result.Status)
+	}
+
+	return em.GetResult(id)
+}
+
+// cancelRemote asks the node running the execution to stop it and waits until
+// the node has recorded the result.
+func (em *ExecutionManager) cancelRemote(result *ExecutionResult, registry RunRegistry, streamer ExecutionStreamer) (*ExecutionResult, error) {
+	if streamer == nil {
+		return nil, errResultNotFound
+	}
+	streamer.PublishCancel(result)
+
+	deadline := time.Now().Add(remoteCancelTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(remoteCancelPoll)
+		if _, _, running := registry.Get(result.ID); !running {
+			return loadResult(result.ID)
+		}
+	}
+	return nil, fmt.Errorf("execution %s did not stop in time", result.ID)
+}
+
+// CancelLocal stops an execution of this node that was cancelled through
+// another node. Executions of other nodes are ignored.
+func (em *ExecutionManager) CancelLocal(id string) {
+	em.mutex.RLock()
+	_, exists := em.results.get(id)
+	em.mutex.RUnlock()
+	if !exists {
+		return
+	}
+	if _, err := em.CancelExecution(id); err != nil {
+		log.Printf("failed to cancel execution %s for another node: %v", id, err)
+	}
+}
+
+func (em *ExecutionManager) outputPublisher(result *ExecutionResult) func(stream string, chunk string) {
+	em.mutex.RLock()
+	streamer := em.streamer
+	em.mutex.RUnlock()
+	if streamer == nil {
+		return nil
+	}
+
+	seq := 0
+	var seqMutex sync.Mutex
+	return func(stream string, chunk string) {
+		seqMutex.Lock()
+		seq++
+		current := seq
+		seqMutex.Unlock()
+		streamer.PublishOutput(result, stream, chunk, current)
+	}
+}
+
+func (em *ExecutionManager) WaitResult(id string) (*ExecutionResult, error) {
+	em.mutex.RLock()
+	done, running := em.done[id]
+	em.mutex.RUnlock()
+
+	if running {
+		<-done
+	}
+	return em.GetResult(id)
 }
 
 func (em *ExecutionManager) GetResult(id string) (*ExecutionResult, error) {
 	em.mutex.RLock()
-	defer em.mutex.RUnlock()
+	if result, exists := em.results.get(id); exists {
+		snapshot := *result
+		em.mutex.RUnlock()
+		if snapshot.Status == "queued" {
+			snapshot.QueuePosition = em.queue.Position(id)
+		}
+		return &snapshot, nil
+	}
+	registry := em.registry
+	em.mutex.RUnlock()
 
-	if result, exists := em.results[id]; exists {
-		return result, nil
+	// queued or running on another node
+	if registry != nil {
+		if result, _, running := registry.Get(id); running {
+			return result, nil
+		}
 	}
+	return loadResult(id)
+}
+
+func (em *ExecutionManager) GetStats() map[string]interface{} {
+	em.mutex.RLock()
+	storedResults := em.results.len()
+	em.mutex.RUnlock()
 
-	return nil, fmt.Errorf("execution result not found")
+	stats := map[string]interface{}{
+		"queue":          em.queue.GetStats(),
+		"stored_results": storedResults,
+	}
+	if reporter, ok := em.executor.(poolReporter); ok {
+		stats["pool"] = reporter.PoolStats()
+	}
+	stats["repl"] = GlobalReplManager.GetStats()
+	return stats
 }
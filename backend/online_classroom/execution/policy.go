@@ -0,0 +1,390 @@
+package execution
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type CodePolicy struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MaxBytes    int    `json:"max_bytes"`
+	MaxLines    int    `json:"max_lines"`
+
+	// AllowedImports, when not empty, is the complete list of importable modules.
+	// An entry also covers its submodules ("os" covers "os.path").
+	AllowedImports []string `json:"allowed_imports,omitempty"`
+	DeniedImports  []string `json:"denied_imports,omitempty"`
+	BannedBuiltins []string `json:"banned_builtins,omitempty"`
+}
+
+type PolicyViolation struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Rule    string `json:"rule"` // "size", "syntax", "import", "builtin"
+	Message string `json:"message"`
+}
+
+type PolicyError struct {
+	Policy     string
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		location := violation.File
+		if violation.Line > 0 {
+			location = strings.TrimSpace(fmt.Sprintf("%s line %d", violation.File, violation.Line))
+		}
+		if location != "" {
+			messages = append(messages, fmt.Sprintf("%s: %s", location, violation.Message))
+		} else {
+			messages = append(messages, violation.Message)
+		}
+	}
+	return fmt.Sprintf("code violates the %q policy: %s", e.Policy, strings.Join(messages, "; "))
+}
+
+// checkSize applies the per source file limits shared by every language.
+func (p *CodePolicy) checkSize(file WorkspaceFile) []PolicyViolation {
+	code := file.Content
+	violations := make([]PolicyViolation, 0)
+	if p.MaxBytes > 0 && len(code) > p.MaxBytes {
+		violations = append(violations, PolicyViolation{
+			File:    file.Path,
+			Rule:    "size",
+			Message: fmt.Sprintf("code is %d bytes, the limit is %d", len(code), p.MaxBytes),
+		})
+	}
+	if lines := strings.Count(code, "\n") + 1; p.MaxLines > 0 && lines > p.MaxLines {
+		violations = append(violations, PolicyViolation{
+			File:    file.Path,
+			Line:    p.MaxLines + 1,
+			Rule:    "size",
+			Message: fmt.Sprintf("code has %d lines, the limit is %d", lines, p.MaxLines),
+		})
+	}
+	return violations
+}
+
+// importAllowed decides on a module import; the student's own modules are always allowed.
+func (p *CodePolicy) importAllowed(module string, localModules map[string]bool) bool {
+	if localModules[strings.Split(module, ".")[0]] {
+		return true
+	}
+	for _, denied := range p.DeniedImports {
+		if moduleMatches(module, denied) {
+			return false
+		}
+	}
+	if len(p.AllowedImports) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedImports {
+		if moduleMatches(module, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func moduleMatches(module string, entry string) bool {
+	return module == entry || strings.HasPrefix(module, entry+".")
+}
+
+// checkPythonWorkspace checks every .py file of the workspace.
+func checkPythonWorkspace(files []WorkspaceFile, policy *CodePolicy) []PolicyViolation {
+	localModules := make(map[string]bool)
+	for _, file := range files {
+		if path.Ext(file.Path) == ".py" {
+			// "utils/helpers.py" is importable as the package "utils", and as
+			// "helpers" from a script next to it
+			modulePath := strings.TrimSuffix(file.Path, ".py")
+			for _, name := range []string{strings.Split(modulePath, "/")[0], path.Base(modulePath)} {
+				// an "os.py" next to main.py does not make "import os" local, the
+				// interpreter already loaded the real one
+				if pythonStdlibModules[name] && !policy.importAllowed(name, nil) {
+					continue
+				}
+				localModules[name] = true
+			}
+		}
+	}
+
+	violations := make([]PolicyViolation, 0)
+	for _, file := range files {
+		if path.Ext(file.Path) != ".py" {
+			continue
+		}
+		for _, violation := range checkPythonPolicy(file.Content, policy, localModules) {
+			violation.File = file.Path
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// checkPythonPolicy enforces imports and banned builtins on the token stream, so
+// comments, strings and spacing ("while  True:") do not change the outcome.
+func checkPythonPolicy(code string, policy *CodePolicy, localModules map[string]bool) []PolicyViolation {
+	tokens, err := tokenizePython(code)
+	if err != nil {
+		line := 0
+		if syntaxErr, ok := err.(*pySyntaxError); ok {
+			line = syntaxErr.line
+		}
+		return []PolicyViolation{{Line: line, Rule: "syntax", Message: err.Error()}}
+	}
+
+	violations := make([]PolicyViolation, 0)
+	for _, statement := range splitPythonStatements(tokens) {
+		rejected := ""
+		for _, imported := range pythonImports(statement) {
+			if strings.HasPrefix(imported.text, ".") {
+				continue // relative import of the student's own files
+			}
+			if rejected != "" && moduleMatches(imported.text, rejected) {
+				continue // "from os import path" is reported once, for os
+			}
+			if !policy.importAllowed(imported.text, localModules) {
+				rejected = imported.text
+				violations = append(violations, PolicyViolation{
+					Line:    imported.line,
+					Rule:    "import",
+					Message: fmt.Sprintf("import of module '%s' is not allowed", imported.text),
+				})
+			}
+		}
+	}
+
+	banned := make(map[string]bool)
+	for _, name := range policy.BannedBuiltins {
+		banned[name] = true
+	}
+	reported := make(map[string]bool)
+	for i, token := range tokens {
+		if token.kind != pyName || !banned[token.text] {
+			continue
+		}
+		// obj.eval(...) is a method, and "def open(...)" defines the user's own name
+		if i > 0 && (tokens[i-1].text == "." || tokens[i-1].text == "def" || tokens[i-1].text == "class") {
+			continue
+		}
+		key := fmt.Sprintf("%d:%s", token.line, token.text)
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		violations = append(violations, PolicyViolation{
+			Line:    token.line,
+			Rule:    "builtin",
+			Message: fmt.Sprintf("use of '%s' is not allowed", token.text),
+		})
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Line < violations[j].Line
+	})
+	return violations
+}
+
+// pythonImports returns the modules named by an import statement. Each result
+// token carries the dotted module path in text.
+func pythonImports(statement []pyToken) []pyToken {
+	if len(statement) < 2 || statement[0].kind != pyName {
+		return nil
+	}
+
+	switch statement[0].text {
+	case "import":
+		modules := make([]pyToken, 0)
+		i := 1
+		for i < len(statement) {
+			module, next := readDottedName(statement, i)
+			if module.text != "" {
+				modules = append(modules, module)
+			}
+			i = next
+			// skip "as alias" up to the next comma
+			for i < len(statement) && statement[i].text != "," {
+				i++
+			}
+			i++
+		}
+		return modules
+	case "from":
+		module, next := readDottedName(statement, 1)
+		if module.text == "" || next >= len(statement) || statement[next].text != "import" {
+			return nil
+		}
+		modules := []pyToken{module}
+		// "from importlib import import_module" and "from os import path" name submodules or members,
+		// check them as module.member so denied submodules are caught too.
+		for i := next + 1; i < len(statement); i++ {
+			token := statement[i]
+			if token.kind != pyName || token.text == "as" || (i > 0 && statement[i-1].text == "as") {
+				continue
+			}
+			if !strings.HasPrefix(module.text, ".") {
+				modules = append(modules, pyToken{kind: pyName, text: module.text + "." + token.text, line: token.line})
+			}
+		}
+		return modules
+	}
+	return nil
+}
+
+// readDottedName reads "a.b.c" (with leading dots for relative imports) from index start.
+func readDottedName(statement []pyToken, start int) (pyToken, int) {
+	var builder strings.Builder
+	line := 0
+	i := start
+	for i < len(statement) {
+		token := statement[i]
+		if token.text == "." || (token.kind == pyName && (builder.Len() == 0 || strings.HasSuffix(builder.String(), "."))) {
+			if line == 0 {
+				line = token.line
+			}
+			builder.WriteString(token.text)
+			i++
+			continue
+		}
+		break
+	}
+	return pyToken{kind: pyName, text: builder.String(), line: line}, i
+}
+
+var DefaultCodePolicy = &CodePolicy{
+	Name:        "default",
+	Description: "Standard library without process, file-system and network modules",
+	MaxBytes:    50000,
+	MaxLines:    2000,
+	DeniedImports: []string{
+		"os", "subprocess", "socket", "shutil", "ctypes", "multiprocessing",
+		"importlib", "builtins", "pty", "signal", "resource", "urllib", "http",
+	},
+	BannedBuiltins: []string{"eval", "exec", "compile", "open", "__import__", "breakpoint", "globals", "__builtins__"},
+}
+
+var StrictCodePolicy = &CodePolicy{
+	Name:        "strict",
+	Description: "Beginner exercises: a short list of computational modules only",
+	MaxBytes:    20000,
+	MaxLines:    500,
+	AllowedImports: []string{
+		"math", "random", "string", "collections", "itertools", "functools", "re",
+		"datetime", "typing", "dataclasses", "statistics", "heapq", "bisect", "sys",
+	},
+	BannedBuiltins: []string{
+		"eval", "exec", "compile", "open", "__import__", "breakpoint", "globals", "locals",
+		"vars", "getattr", "setattr", "delattr", "__builtins__",
+	},
+}
+
+var RelaxedCodePolicy = &CodePolicy{
+	Name:           "relaxed",
+	Description:    "Advanced classes: only process and raw network access are blocked",
+	MaxBytes:       50000,
+	MaxLines:       5000,
+	DeniedImports:  []string{"subprocess", "socket", "ctypes", "pty"},
+	BannedBuiltins: []string{"eval", "exec", "__import__"},
+}
+
+// PolicyRegistry holds the built-in policies; the choice of each class is stored
+// with the class, so it survives restarts and is the same on every node.
+type PolicyRegistry struct {
+	policies map[string]*CodePolicy
+	mutex    sync.RWMutex
+}
+
+func NewPolicyRegistry() *PolicyRegistry {
+	return &PolicyRegistry{
+		policies: make(map[string]*CodePolicy),
+	}
+}
+
+var GlobalPolicyRegistry = NewPolicyRegistry()
+
+func init() {
+	GlobalPolicyRegistry.Register(DefaultCodePolicy)
+	GlobalPolicyRegistry.Register(StrictCodePolicy)
+	GlobalPolicyRegistry.Register(RelaxedCodePolicy)
+}
+
+func (pr *PolicyRegistry) Register(policy *CodePolicy) {
+	pr.mutex.Lock()
+	defer pr.mutex.Unlock()
+	pr.policies[policy.Name] = policy
+}
+
+func (pr *PolicyRegistry) Get(name string) (*CodePolicy, error) {
+	pr.mutex.RLock()
+	defer pr.mutex.RUnlock()
+
+	if policy, exists := pr.policies[name]; exists {
+		return policy, nil
+	}
+	return nil, fmt.Errorf("unknown code policy: %s", name)
+}
+
+func (pr *PolicyRegistry) List() []*CodePolicy {
+	pr.mutex.RLock()
+	defer pr.mutex.RUnlock()
+
+	policies := make([]*CodePolicy, 0, len(pr.policies))
+	for _, policy := range pr.policies {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	return policies
+}
+
+func (pr *PolicyRegistry) SetClassPolicy(classID uint, name string) error {
+	if _, err := pr.Get(name); err != nil {
+		return err
+	}
+	if configs.ExecutionProfileApplications == nil {
+		return errors.New("class policies can not be stored yet")
+	}
+	return configs.ExecutionProfileApplications.SaveClassPolicy(&entities.ClassPolicy{
+		ClassID:    classID,
+		PolicyName: name,
+	})
+}
+
+// PolicyForClass returns the policy the class chose, the default one when it
+// chose none or a policy that no longer exists.
+func (pr *PolicyRegistry) PolicyForClass(classID uint) *CodePolicy {
+	if configs.ExecutionProfileApplications == nil {
+		return DefaultCodePolicy
+	}
+	classPolicy, err := configs.ExecutionProfileApplications.FindClassPolicy(classID)
+	if err != nil {
+		return DefaultCodePolicy
+	}
+	if policy, err := pr.Get(classPolicy.PolicyName); err == nil {
+		return policy
+	}
+	return DefaultCodePolicy
+}
+
+// PolicyForLecture resolves the policy of the class the lecture belongs to.
+func (pr *PolicyRegistry) PolicyForLecture(lectureID uint) *CodePolicy {
+	if configs.ClassApplications == nil {
+		return DefaultCodePolicy
+	}
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(lectureID)
+	if err != nil {
+		return DefaultCodePolicy
+	}
+	return pr.PolicyForClass(lecture.ClassID)
+}
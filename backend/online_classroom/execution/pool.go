@@ -0,0 +1,204 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPoolSize is the number of idle containers kept per language
+	DefaultPoolSize = 2
+
+	// pooled containers are replaced after this long, so a long-lived pool never
+	// runs into the sleep that bounds a leaked container's lifetime
+	pooledContainerMaxAge   = 30 * time.Minute
+	pooledContainerLifetime = 2 * time.Hour
+)
+
+// ContainerPool keeps started, network-less containers per language so a run
+// only pays for an exec. Every container serves one run and is then removed;
+// taking one starts its replacement in the background.
+//
+// Containers are created with the language's DefaultConfig: a run with other
+// limits (a teacher's) starts its own container.
+type ContainerPool struct {
+	engine ContainerEngine
+	size   int
+
+	idle    map[string][]pooledContainer // by language
+	filling map[string]int
+	health  map[string]*poolHealth
+	mutex   sync.Mutex
+}
+
+type pooledContainer struct {
+	id        string
+	createdAt time.Time
+}
+
+type poolHealth struct {
+	hits      int64
+	misses    int64
+	failures  int64
+	lastError string
+	lastErrAt time.Time
+}
+
+func NewContainerPool(engine ContainerEngine, size int) *ContainerPool {
+	return &ContainerPool{
+		engine:  engine,
+		size:    size,
+		idle:    make(map[string][]pooledContainer),
+		filling: make(map[string]int),
+		health:  make(map[string]*poolHealth),
+	}
+}
+
+// Start fills the pool of every registered language in the background.
+func (cp *ContainerPool) Start() {
+	for _, lang := range GlobalLanguageRegistry.List() {
+		go cp.refill(lang)
+	}
+}
+
+// Acquire hands out an idle container for a run of lang with config. ok is
+// false when the run needs a container of its own.
+func (cp *ContainerPool) Acquire(lang *LanguageSpec, config ExecutionConfig) (string, bool) {
+	if cp.size <= 0 || config != lang.DefaultConfig {
+		return "", false
+	}
+	// pooled containers run the default image, a class runtime needs its own
+	if registered, err := GlobalLanguageRegistry.Get(lang.Name); err != nil || registered.Image != lang.Image {
+		return "", false
+	}
+
+	cp.mutex.Lock()
+	health := cp.healthLocked(lang.Name)
+	var acquired *pooledContainer
+	stale := make([]string, 0)
+	for len(cp.idle[lang.Name]) > 0 && acquired == nil {
+		container := cp.idle[lang.Name][0]
+		cp.idle[lang.Name] = cp.idle[lang.Name][1:]
+		if time.Since(container.createdAt) > pooledContainerMaxAge {
+			stale = append(stale, container.id)
+			continue
+		}
+		acquired = &container
+	}
+	if acquired != nil {
+		health.hits++
+	} else {
+		health.misses++
+	}
+	cp.mutex.Unlock()
+
+	for _, id := range stale {
+		go cp.remove(id)
+	}
+	go cp.refill(lang)
+
+	if acquired == nil {
+		return "", false
+	}
+	return acquired.id, true
+}
+
+// refill starts containers until the language has size idle or starting ones.
+func (cp *ContainerPool) refill(lang *LanguageSpec) {
+	for {
+		cp.mutex.Lock()
+		if len(cp.idle[lang.Name])+cp.filling[lang.Name] >= cp.size {
+			cp.mutex.Unlock()
+			return
+		}
+		cp.filling[lang.Name]++
+		cp.mutex.Unlock()
+
+		id, err := cp.startContainer(lang)
+
+		cp.mutex.Lock()
+		cp.filling[lang.Name]--
+		if err != nil {
+			health := cp.healthLocked(lang.Name)
+			health.failures++
+			health.lastError = err.Error()
+			health.lastErrAt = time.Now()
+			cp.mutex.Unlock()
+			// the next Acquire tries again, a missing image should not spin here
+			log.Printf("WARNING: failed to start a pooled %s container: %v", lang.Name, err)
+			return
+		}
+		cp.idle[lang.Name] = append(cp.idle[lang.Name], pooledContainer{id: id, createdAt: time.Now()})
+		cp.mutex.Unlock()
+	}
+}
+
+func (cp *ContainerPool) startContainer(lang *LanguageSpec) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	name := fmt.Sprintf("zcode_pool_%s_%s", lang.Name, generateExecutionID())
+	spec := containerSpec(name, lang, lang.DefaultConfig, pooledContainerLifetime)
+	spec.Labels["zcode.pool"] = lang.Name
+	id, err := cp.engine.CreateContainer(ctx, spec)
+	if err != nil {
+		return "", err
+	}
+	if err := cp.engine.StartContainer(ctx, id); err != nil {
+		cp.remove(id)
+		return "", err
+	}
+	return id, nil
+}
+
+func (cp *ContainerPool) remove(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := cp.engine.RemoveContainer(ctx, id); err != nil {
+		log.Printf("WARNING: failed to remove pooled container %s: %v", id, err)
+	}
+}
+
+func (cp *ContainerPool) healthLocked(language string) *poolHealth {
+	health, exists := cp.health[language]
+	if !exists {
+		health = &poolHealth{}
+		cp.health[language] = health
+	}
+	return health
+}
+
+// GetStats reports per language how full the pool is and how often it helped.
+// A language is unhealthy while its pool is empty because containers fail to start.
+func (cp *ContainerPool) GetStats() map[string]interface{} {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+
+	languages := make(map[string]interface{})
+	for _, lang := range GlobalLanguageRegistry.List() {
+		health := cp.healthLocked(lang.Name)
+		idle := len(cp.idle[lang.Name])
+		stats := map[string]interface{}{
+			"idle":     idle,
+			"starting": cp.filling[lang.Name],
+			"hits":     health.hits,
+			"misses":   health.misses,
+			"failures": health.failures,
+			"healthy":  idle > 0 || health.lastError == "" || cp.filling[lang.Name] > 0,
+		}
+		if health.lastError != "" {
+			stats["last_error"] = health.lastError
+			stats["last_error_at"] = health.lastErrAt
+		}
+		languages[lang.Name] = stats
+	}
+	return map[string]interface{}{
+		"enabled":   cp.size > 0,
+		"size":      cp.size,
+		"languages": languages,
+	}
+}
@@ -0,0 +1,194 @@
+package execution
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Upper bounds of a stored profile, so a class cannot take over the host.
+const (
+	MaxProfileTimeoutSeconds = 120
+	MaxProfileMemoryLimitMB  = 2048
+	MaxProfileCPULimit       = 4.0
+	MaxProfileOutputLimitKB  = 8192
+)
+
+var packageNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// ResolvedProfile is what a run of a class role gets in a lecture.
+type ResolvedProfile struct {
+	Role     string                     `json:"role"`
+	Config   ExecutionConfig            `json:"config"`
+	Policy   *CodePolicy                `json:"policy"`
+	Profile  *entities.ExecutionProfile `json:"profile,omitempty"` // nil when no stored profile applies
+	Runtime  *entities.RuntimeImage     `json:"runtime,omitempty"` // nil when the class uses the default image
+	Image    string                     `json:"image"`
+	Language *LanguageSpec              `json:"-"` // the language spec running in Image
+}
+
+// ResolveProfile applies the best matching stored profile of the lecture's class
+// to the built-in limits of the role. A lecture profile beats a class-wide one,
+// then a profile for the language beats one for every language, then one for the
+// role beats one for every role; among equals the newest wins. The run uses the
+// runtime image the class chose for the language.
+func ResolveProfile(lang *LanguageSpec, lectureID uint, role string) *ResolvedProfile {
+	resolved := &ResolvedProfile{
+		Role:     role,
+		Config:   lang.ConfigForRole(role),
+		Policy:   GlobalPolicyRegistry.PolicyForLecture(lectureID),
+		Image:    lang.Image,
+		Language: lang,
+	}
+	if configs.ClassApplications == nil {
+		return resolved
+	}
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(lectureID)
+	if err != nil {
+		return resolved
+	}
+
+	if profile := findProfile(lang.Name, lecture.ClassID, lectureID, role); profile != nil {
+		resolved.Profile = profile
+		resolved.Config = applyProfile(profile, resolved.Config)
+		if packages := SplitAllowedPackages(profile.AllowedPackages); len(packages) > 0 {
+			resolved.Policy = policyWithPackages(resolved.Policy, profile.Name, packages)
+		}
+	}
+
+	if runtime := findClassRuntime(lecture.ClassID, lang.Name); runtime != nil {
+		resolved.Runtime = runtime
+		resolved.Image = runtime.Image
+		resolved.Language = lang.withImage(runtime.Image)
+		// an allowlist would block the packages the image was built for
+		if packages := SplitAllowedPackages(runtime.Packages); len(packages) > 0 && len(resolved.Policy.AllowedImports) > 0 {
+			allowed := append(append([]string{}, resolved.Policy.AllowedImports...), packages...)
+			resolved.Policy = policyWithPackages(resolved.Policy, runtime.Name, allowed)
+		}
+	}
+	return resolved
+}
+
+func findProfile(language string, classID uint, lectureID uint, role string) *entities.ExecutionProfile {
+	if configs.ExecutionProfileApplications == nil {
+		return nil
+	}
+	profiles, err := configs.ExecutionProfileApplications.FindExecutionProfilesForLecture(classID, lectureID)
+	if err != nil {
+		log.Printf("WARNING: failed to load execution profiles of lecture %d: %v", lectureID, err)
+		return nil
+	}
+
+	var best *entities.ExecutionProfile
+	bestScore := -1
+	for _, profile := range profiles {
+		if profile.LectureID != 0 && profile.LectureID != lectureID {
+			continue
+		}
+		if profile.Language != "" && profile.Language != language {
+			continue
+		}
+		if profile.UserRole != "" && profile.UserRole != role {
+			continue
+		}
+		score := 0
+		if profile.LectureID != 0 {
+			score += 4
+		}
+		if profile.Language != "" {
+			score += 2
+		}
+		if profile.UserRole != "" {
+			score++
+		}
+		// profiles come in id order, so >= keeps the newest of equals
+		if score >= bestScore {
+			best = profile
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// applyProfile overrides the limits the profile sets, zero keeps the built-in value.
+func applyProfile(p *entities.ExecutionProfile, config ExecutionConfig) ExecutionConfig {
+	if p.TimeoutSeconds > 0 {
+		config.TimeoutSeconds = p.TimeoutSeconds
+	}
+	if p.MemoryLimitMB > 0 {
+		config.MemoryLimitMB = p.MemoryLimitMB
+	}
+	if p.CPULimit > 0 {
+		config.CPULimit = p.CPULimit
+	}
+	if p.OutputLimitKB > 0 {
+		config.OutputLimitKB = p.OutputLimitKB
+	}
+	return config
+}
+
+// policyWithPackages is a copy of policy where packages is the complete list of
+// importable modules. Listing a module the policy denies allows it.
+func policyWithPackages(policy *CodePolicy, profileName string, packages []string) *CodePolicy {
+	restricted := *policy
+	restricted.Name = fmt.Sprintf("%s (%s)", policy.Name, profileName)
+	restricted.AllowedImports = packages
+	restricted.DeniedImports = make([]string, 0, len(policy.DeniedImports))
+	for _, denied := range policy.DeniedImports {
+		listed := false
+		for _, allowed := range packages {
+			if moduleMatches(denied, allowed) {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			restricted.DeniedImports = append(restricted.DeniedImports, denied)
+		}
+	}
+	return &restricted
+}
+
+// SplitAllowedPackages reads the comma separated column of a profile.
+func SplitAllowedPackages(value string) []string {
+	packages := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			packages = append(packages, name)
+		}
+	}
+	return packages
+}
+
+// ValidateProfile checks a profile before it is stored.
+func ValidateProfile(profile *entities.ExecutionProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if profile.Language != "" {
+		if _, err := GlobalLanguageRegistry.Get(profile.Language); err != nil {
+			return err
+		}
+	}
+	if profile.TimeoutSeconds < 0 || profile.TimeoutSeconds > MaxProfileTimeoutSeconds {
+		return fmt.Errorf("timeout can be at most %d seconds", MaxProfileTimeoutSeconds)
+	}
+	if profile.MemoryLimitMB < 0 || profile.MemoryLimitMB > MaxProfileMemoryLimitMB {
+		return fmt.Errorf("memory limit can be at most %d MB", MaxProfileMemoryLimitMB)
+	}
+	if profile.CPULimit < 0 || profile.CPULimit > MaxProfileCPULimit {
+		return fmt.Errorf("cpu limit can be at most %.0f", MaxProfileCPULimit)
+	}
+	if profile.OutputLimitKB < 0 || profile.OutputLimitKB > MaxProfileOutputLimitKB {
+		return fmt.Errorf("output limit can be at most %d KB", MaxProfileOutputLimitKB)
+	}
+	for _, name := range SplitAllowedPackages(profile.AllowedPackages) {
+		if !packageNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid package name: %s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package execution
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type pyTokenKind int
+
+const (
+	pyName pyTokenKind = iota
+	pyNumber
+	pyString
+	pyOp
+	pyNewline // end of a logical line
+)
+
+type pyToken struct {
+	kind pyTokenKind
+	text string
+	line int
+}
+
+type pySyntaxError struct {
+	line    int
+	message string
+}
+
+func (e *pySyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.message)
+}
+
+// tokenizePython splits Python source into tokens, following the lexical rules
+// the policy checks depend on: comments and string contents are never mistaken
+// for code, brackets join physical lines, and f-string replacement fields are
+// tokenized as code.
+func tokenizePython(code string) ([]pyToken, error) {
+	t := &pyTokenizer{src: code, line: 1}
+	if err := t.run(); err != nil {
+		return nil, err
+	}
+	return t.tokens, nil
+}
+
+type pyTokenizer struct {
+	src    string
+	pos    int
+	line   int
+	depth  int // open brackets
+	tokens []pyToken
+
+	// the source ended inside a string or right after a backslash continuation
+	incomplete bool
+}
+
+func (t *pyTokenizer) emit(kind pyTokenKind, text string, line int) {
+	t.tokens = append(t.tokens, pyToken{kind: kind, text: text, line: line})
+}
+
+func (t *pyTokenizer) run() error {
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case c == '\n':
+			if t.depth == 0 {
+				t.emit(pyNewline, "\n", t.line)
+			}
+			t.line++
+			t.pos++
+		case c == '\\' && t.pos+1 < len(t.src) && (t.src[t.pos+1] == '\n' || t.src[t.pos+1] == '\r'):
+			// explicit line continuation, the next line belongs to the same logical line
+			t.pos++
+			if t.src[t.pos] == '\r' {
+				t.pos++
+			}
+			if t.pos < len(t.src) && t.src[t.pos] == '\n' {
+				t.pos++
+			}
+			t.line++
+			t.incomplete = t.pos >= len(t.src)
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			t.pos++
+		case c == '#':
+			for t.pos < len(t.src) && t.src[t.pos] != '\n' {
+				t.pos++
+			}
+		case c == '"' || c == '\'':
+			if err := t.readString(""); err != nil {
+				return err
+			}
+		case isDigit(c) || (c == '.' && t.pos+1 < len(t.src) && isDigit(t.src[t.pos+1])):
+			t.readNumber()
+		case c == '_' || c >= utf8.RuneSelf || unicode.IsLetter(rune(c)):
+			start := t.pos
+			t.readName()
+			name := t.src[start:t.pos]
+			if t.pos < len(t.src) && (t.src[t.pos] == '"' || t.src[t.pos] == '\'') && isStringPrefix(name) {
+				if err := t.readString(strings.ToLower(name)); err != nil {
+					return err
+				}
+				continue
+			}
+			t.emit(pyName, name, t.line)
+		default:
+			switch c {
+			case '(', '[', '{':
+				t.depth++
+			case ')', ']', '}':
+				if t.depth > 0 {
+					t.depth--
+				}
+			}
+			t.emit(pyOp, string(c), t.line)
+			t.pos++
+		}
+	}
+	if len(t.tokens) > 0 && t.tokens[len(t.tokens)-1].kind != pyNewline {
+		t.emit(pyNewline, "", t.line)
+	}
+	return nil
+}
+
+// pythonInputIncomplete reports whether code ends inside a statement that goes
+// on in the next line: an open bracket, a backslash continuation or a string
+// that is still open.
+func pythonInputIncomplete(code string) bool {
+	t := &pyTokenizer{src: code, line: 1}
+	if err := t.run(); err != nil {
+		return t.incomplete
+	}
+	return t.incomplete || t.depth > 0
+}
+
+func (t *pyTokenizer) readName() {
+	for t.pos < len(t.src) {
+		r, size := utf8.DecodeRuneInString(t.src[t.pos:])
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		t.pos += size
+	}
+}
+
+func (t *pyTokenizer) readNumber() {
+	start := t.pos
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		if isDigit(c) || c == '.' || c == '_' || unicode.IsLetter(rune(c)) {
+			t.pos++
+			continue
+		}
+		// exponent sign, as in 1e-5
+		if (c == '+' || c == '-') && (t.src[t.pos-1] == 'e' || t.src[t.pos-1] == 'E') && !strings.HasPrefix(strings.ToLower(t.src[start:]), "0x") {
+			t.pos++
+			continue
+		}
+		break
+	}
+	t.emit(pyNumber, t.src[start:t.pos], t.line)
+}
+
+// readString consumes a string literal starting at the opening quote.
+func (t *pyTokenizer) readString(prefix string) error {
+	startLine := t.line
+	quote := t.src[t.pos]
+	triple := strings.HasPrefix(t.src[t.pos:], strings.Repeat(string(quote), 3))
+	delimiter := string(quote)
+	if triple {
+		delimiter = strings.Repeat(string(quote), 3)
+	}
+	t.pos += len(delimiter)
+	bodyStart := t.pos
+
+	for {
+		if t.pos >= len(t.src) {
+			t.incomplete = true
+			return &pySyntaxError{line: startLine, message: "unterminated string literal"}
+		}
+		c := t.src[t.pos]
+		if c == '\\' {
+			if t.pos+1 < len(t.src) && t.src[t.pos+1] == '\n' {
+				t.line++
+			}
+			t.pos += 2
+			continue
+		}
+		if c == '\n' {
+			if !triple {
+				return &pySyntaxError{line: startLine, message: "unterminated string literal"}
+			}
+			t.line++
+		}
+		if strings.HasPrefix(t.src[t.pos:], delimiter) {
+			break
+		}
+		t.pos++
+	}
+
+	body := t.src[bodyStart:t.pos]
+	t.pos += len(delimiter)
+	t.emit(pyString, body, startLine)
+
+	if strings.Contains(prefix, "f") {
+		t.tokenizeFStringFields(body, startLine)
+	}
+	return nil
+}
+
+// tokenizeFStringFields appends the tokens of every {expression} in an f-string body.
+func (t *pyTokenizer) tokenizeFStringFields(body string, line int) {
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\n' {
+			line++
+			continue
+		}
+		if body[i] != '{' {
+			continue
+		}
+		if i+1 < len(body) && body[i+1] == '{' {
+			i++
+			continue
+		}
+
+		depth := 1
+		end := i + 1
+		for end < len(body) && depth > 0 {
+			switch body[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			end++
+		}
+		inner := &pyTokenizer{src: body[i+1 : end-1], line: line, depth: 1}
+		if inner.run() == nil && len(inner.tokens) > 0 {
+			// drop the closing newline so the field stays part of the enclosing statement
+			t.tokens = append(t.tokens, inner.tokens[:len(inner.tokens)-1]...)
+		}
+		line += strings.Count(body[i:end], "\n")
+		i = end - 1
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isStringPrefix(name string) bool {
+	switch strings.ToLower(name) {
+	case "r", "u", "b", "f", "br", "rb", "fr", "rf":
+		return true
+	}
+	return false
+}
+
+// splitPythonStatements groups tokens into simple statements, splitting on
+// logical line ends, ';' and the ':' that opens a block on the same line.
+func splitPythonStatements(tokens []pyToken) [][]pyToken {
+	statements := make([][]pyToken, 0)
+	current := make([]pyToken, 0)
+	depth := 0
+
+	flush := func() {
+		if len(current) > 0 {
+			statements = append(statements, current)
+			current = make([]pyToken, 0)
+		}
+	}
+
+	for _, token := range tokens {
+		if token.kind == pyNewline {
+			flush()
+			depth = 0
+			continue
+		}
+		if token.kind == pyOp {
+			switch token.text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			case ";":
+				if depth == 0 {
+					flush()
+					continue
+				}
+			case ":":
+				if depth == 0 && len(current) > 0 && isBlockKeyword(current[0].text) {
+					current = append(current, token)
+					flush()
+					continue
+				}
+			}
+		}
+		current = append(current, token)
+	}
+	flush()
+	return statements
+}
+
+func isBlockKeyword(word string) bool {
+	switch word {
+	case "if", "elif", "else", "for", "while", "try", "except", "finally", "with", "def", "class", "async":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package execution
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrQueueFull = errors.New("execution queue is full, please try again later")
+var ErrTooManyQueued = errors.New("too many executions waiting, wait for your previous runs to finish")
+
+type QueueConfig struct {
+	Workers          int `json:"workers"`
+	MaxQueueSize     int `json:"max_queue_size"`
+	MaxQueuedPerUser int `json:"max_queued_per_user"`
+	PerUserLimit     int `json:"per_user_limit"`    // concurrent runs per user
+	PerLectureLimit  int `json:"per_lecture_limit"` // concurrent runs per lecture
+}
+
+var DefaultQueueConfig = QueueConfig{
+	Workers:          4,
+	MaxQueueSize:     200,
+	MaxQueuedPerUser: 3,
+	PerUserLimit:     1,
+	PerLectureLimit:  3,
+}
+
+type queuedJob struct {
+	id        string
+	userKey   string
+	lectureID uint
+	run       func()
+}
+
+// ExecutionQueue dispatches jobs round-robin across users, so one student
+// pressing Run repeatedly cannot push everybody else back.
+type ExecutionQueue struct {
+	config QueueConfig
+
+	pending   map[string][]*queuedJob // user -> FIFO
+	userOrder []string                // round-robin ring of users with pending jobs
+	cursor    int
+	size      int
+
+	runningByUser    map[string]int
+	runningByLecture map[uint]int
+	running          int
+
+	mutex sync.Mutex
+	cond  *sync.Cond
+}
+
+func NewExecutionQueue(config QueueConfig) *ExecutionQueue {
+	eq := &ExecutionQueue{
+		config:           config,
+		pending:          make(map[string][]*queuedJob),
+		userOrder:        make([]string, 0),
+		runningByUser:    make(map[string]int),
+		runningByLecture: make(map[uint]int),
+	}
+	eq.cond = sync.NewCond(&eq.mutex)
+
+	for i := 0; i < config.Workers; i++ {
+		go eq.worker()
+	}
+	return eq
+}
+
+func (eq *ExecutionQueue) Enqueue(id string, lectureID uint, userZCode string, run func()) error {
+	eq.mutex.Lock()
+	defer eq.mutex.Unlock()
+
+	if eq.size >= eq.config.MaxQueueSize {
+		return ErrQueueFull
+	}
+	userKey := userZCode
+	if len(eq.pending[userKey]) >= eq.config.MaxQueuedPerUser {
+		return ErrTooManyQueued
+	}
+
+	if len(eq.pending[userKey]) == 0 {
+		eq.userOrder = append(eq.userOrder, userKey)
+	}
+	eq.pending[userKey] = append(eq.pending[userKey], &queuedJob{
+		id:        id,
+		userKey:   userKey,
+		lectureID: lectureID,
+		run:       run,
+	})
+	eq.size++
+	eq.cond.Signal()
+	return nil
+}
+
+// Remove drops a job that has not started yet.
+func (eq *ExecutionQueue) Remove(id string) bool {
+	eq.mutex.Lock()
+	defer eq.mutex.Unlock()
+
+	for userKey, jobs := range eq.pending {
+		for i, job := range jobs {
+			if job.id == id {
+				eq.pending[userKey] = append(jobs[:i], jobs[i+1:]...)
+				eq.size--
+				if len(eq.pending[userKey]) == 0 {
+					eq.dropUser(userKey)
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Position returns the 1-based place of a job in dispatch order, or 0 if it is not queued.
+func (eq *ExecutionQueue) Position(id string) int {
+	eq.mutex.Lock()
+	defer eq.mutex.Unlock()
+
+	position := 0
+	for round := 0; round < eq.config.MaxQueuedPerUser; round++ {
+		for i := range eq.userOrder {
+			userKey := eq.userOrder[(eq.cursor+i)%len(eq.userOrder)]
+			jobs := eq.pending[userKey]
+			if round >= len(jobs) {
+				continue
+			}
+			position++
+			if jobs[round].id == id {
+				return position
+			}
+		}
+	}
+	return 0
+}
+
+func (eq *ExecutionQueue) worker() {
+	for {
+		eq.mutex.Lock()
+		job := eq.next()
+		for job == nil {
+			eq.cond.Wait()
+			job = eq.next()
+		}
+		eq.running++
+		eq.runningByUser[job.userKey]++
+		eq.runningByLecture[job.lectureID]++
+		eq.mutex.Unlock()
+
+		job.run()
+
+		eq.mutex.Lock()
+		eq.running--
+		eq.runningByUser[job.userKey]--
+		if eq.runningByUser[job.userKey] == 0 {
+			delete(eq.runningByUser, job.userKey)
+		}
+		eq.runningByLecture[job.lectureID]--
+		if eq.runningByLecture[job.lectureID] == 0 {
+			delete(eq.runningByLecture, job.lectureID)
+		}
+		eq.cond.Broadcast()
+		eq.mutex.Unlock()
+	}
+}
+
+// next pops the first eligible job, walking the user ring from the cursor. Caller holds the mutex.
+func (eq *ExecutionQueue) next() *queuedJob {
+	for i := 0; i < len(eq.userOrder); i++ {
+		index := (eq.cursor + i) % len(eq.userOrder)
+		userKey := eq.userOrder[index]
+		job := eq.pending[userKey][0]
+
+		if eq.runningByUser[userKey] >= eq.config.PerUserLimit {
+			continue
+		}
+		if eq.runningByLecture[job.lectureID] >= eq.config.PerLectureLimit {
+			continue
+		}
+
+		eq.pending[userKey] = eq.pending[userKey][1:]
+		eq.size--
+		if len(eq.pending[userKey]) == 0 {
+			eq.dropUser(userKey)
+			eq.cursor = index
+		} else {
+			eq.cursor = index + 1
+		}
+		if len(eq.userOrder) > 0 {
+			eq.cursor %= len(eq.userOrder)
+		} else {
+			eq.cursor = 0
+		}
+		return job
+	}
+	return nil
+}
+
+func (eq *ExecutionQueue) dropUser(userKey string) {
+	delete(eq.pending, userKey)
+	for i, key := range eq.userOrder {
+		if key == userKey {
+			eq.userOrder = append(eq.userOrder[:i], eq.userOrder[i+1:]...)
+			if i < eq.cursor {
+				eq.cursor--
+			}
+			break
+		}
+	}
+	if len(eq.userOrder) == 0 {
+		eq.cursor = 0
+	}
+}
+
+func (eq *ExecutionQueue) GetStats() map[string]interface{} {
+	eq.mutex.Lock()
+	defer eq.mutex.Unlock()
+
+	return map[string]interface{}{
+		"workers":        eq.config.Workers,
+		"running":        eq.running,
+		"queued":         eq.size,
+		"waiting_users":  len(eq.userOrder),
+		"max_queue_size": eq.config.MaxQueueSize,
+	}
+}
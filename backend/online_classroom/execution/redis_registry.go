@@ -0,0 +1,155 @@
+package execution
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/goccy/go-json"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// a run whose node stops refreshing it is gone, e.g. with a crashed node
+	runKeyTTL       = 30 * time.Second
+	runKeyHeartbeat = 10 * time.Second
+	userRunsTTL     = 24 * time.Hour
+)
+
+// admitScript counts the user's runs that are still alive, forgetting the
+// expired ones, and records the new run if the user is below the limit.
+var admitScript = redis.NewScript(`
+local active = 0
+for _, id in ipairs(redis.call('SMEMBERS', KEYS[1])) do
+	if redis.call('EXISTS', ARGV[1] .. id) == 1 then
+		active = active + 1
+	else
+		redis.call('SREM', KEYS[1], id)
+	end
+end
+if active >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[4], 'PX', ARGV[5])
+redis.call('SADD', KEYS[1], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[6])
+return 1
+`)
+
+const (
+	runKeyPrefix      = "classroom:execution:"
+	userRunsKeyPrefix = "classroom:executions:user:"
+)
+
+type registeredRun struct {
+	Node   string           `json:"node"`
+	Result *ExecutionResult `json:"result"`
+}
+
+// RedisRunRegistry keeps a snapshot of every queued or running run in Redis.
+// The node running it refreshes the key, the runs of a node that died expire.
+type RedisRunRegistry struct {
+	client *redis.Client
+	node   string
+
+	local map[string]string // run id -> user zcode, the runs of this node
+	mutex sync.Mutex
+}
+
+func NewRedisRunRegistry(client *redis.Client, node string) *RedisRunRegistry {
+	r := &RedisRunRegistry{client: client, node: node, local: make(map[string]string)}
+	go r.heartbeat()
+	return r
+}
+
+func (r *RedisRunRegistry) payload(result *ExecutionResult) ([]byte, error) {
+	return json.Marshal(registeredRun{Node: r.node, Result: result})
+}
+
+// Admit lets the run through when Redis does not answer, the node's own
+// queue limits still apply.
+func (r *RedisRunRegistry) Admit(result *ExecutionResult, limit int) error {
+	payload, err := r.payload(result)
+	if err != nil {
+		return err
+	}
+	keys := []string{userRunsKeyPrefix + result.UserZCode, runKeyPrefix + result.ID}
+	admitted, err := admitScript.Run(context.Background(), r.client, keys,
+		runKeyPrefix, limit, result.ID, payload, runKeyTTL.Milliseconds(), userRunsTTL.Milliseconds()).Int()
+	if err != nil {
+		log.Printf("execution registry: failed to admit %s: %v", result.ID, err)
+	} else if admitted == 0 {
+		return ErrTooManyQueued
+	}
+
+	r.mutex.Lock()
+	r.local[result.ID] = result.UserZCode
+	r.mutex.Unlock()
+	return nil
+}
+
+func (r *RedisRunRegistry) Update(result *ExecutionResult) {
+	payload, err := r.payload(result)
+	if err != nil {
+		return
+	}
+	if err := r.client.Set(context.Background(), runKeyPrefix+result.ID, payload, runKeyTTL).Err(); err != nil {
+		log.Printf("execution registry: failed to update %s: %v", result.ID, err)
+	}
+}
+
+func (r *RedisRunRegistry) Finish(result *ExecutionResult) {
+	r.mutex.Lock()
+	delete(r.local, result.ID)
+	r.mutex.Unlock()
+
+	ctx := context.Background()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, runKeyPrefix+result.ID)
+		pipe.SRem(ctx, userRunsKeyPrefix+result.UserZCode, result.ID)
+		return nil
+	})
+	if err != nil {
+		log.Printf("execution registry: failed to finish %s: %v", result.ID, err)
+	}
+}
+
+func (r *RedisRunRegistry) Get(id string) (*ExecutionResult, string, bool) {
+	payload, err := r.client.Get(context.Background(), runKeyPrefix+id).Bytes()
+	if err != nil {
+		return nil, "", false
+	}
+	var run registeredRun
+	if err := json.Unmarshal(payload, &run); err != nil || run.Result == nil {
+		return nil, "", false
+	}
+	return run.Result, run.Node, true
+}
+
+func (r *RedisRunRegistry) heartbeat() {
+	ticker := time.NewTicker(runKeyHeartbeat)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.mutex.Lock()
+		ids := make([]string, 0, len(r.local))
+		for id := range r.local {
+			ids = append(ids, id)
+		}
+		r.mutex.Unlock()
+		if len(ids) == 0 {
+			continue
+		}
+
+		ctx := context.Background()
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, id := range ids {
+				pipe.Expire(ctx, runKeyPrefix+id, runKeyTTL)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("execution registry: heartbeat failed: %v", err)
+		}
+	}
+}
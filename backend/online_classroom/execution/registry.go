@@ -0,0 +1,25 @@
+package execution
+
+// RunRegistry shares the queued and running executions of every backend node,
+// so any node can report the status of a run and the per-user queue limit
+// holds across the cluster. Finished runs are read from the executions table.
+// Without a registry, with a single node, only the local manager is asked.
+type RunRegistry interface {
+	// Admit records a new queued run, or refuses it with ErrTooManyQueued when
+	// the user already has limit runs queued or running on any node.
+	Admit(result *ExecutionResult, limit int) error
+	// Update replaces the snapshot of a run of this node.
+	Update(result *ExecutionResult)
+	// Finish forgets a run once its result is persisted.
+	Finish(result *ExecutionResult)
+	// Get returns the snapshot of a queued or running run and the node running it.
+	Get(id string) (*ExecutionResult, string, bool)
+}
+
+// SetRegistry shares the runs of this node with the others, before the server
+// accepts requests.
+func (em *ExecutionManager) SetRegistry(registry RunRegistry) {
+	em.mutex.Lock()
+	defer em.mutex.Unlock()
+	em.registry = registry
+}
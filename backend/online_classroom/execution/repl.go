@@ -0,0 +1,435 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MaxReplSessionsPerLecture = 10
+	ReplIdleTimeout           = 5 * time.Minute
+	ReplMaxLifetime           = time.Hour
+	MaxReplInputBytes         = 16 * 1024
+
+	replJanitorInterval = 30 * time.Second
+)
+
+// reasons a session ends, sent with the closed message
+const (
+	ReplClosedByUser   = "closed"
+	ReplClosedIdle     = "idle_timeout"
+	ReplClosedLifetime = "lifetime_exceeded"
+	ReplClosedExited   = "exited"
+	ReplClosedOutput   = "output_limit"
+	ReplClosedReplaced = "replaced"
+	ReplClosedLeft     = "user_left"
+)
+
+var ErrReplUnsupported = errors.New("interactive sessions are not available for this language")
+var ErrTooManyReplSessions = fmt.Errorf("this lecture already has %d interactive sessions", MaxReplSessionsPerLecture)
+var errReplSessionNotFound = errors.New("interactive session not found")
+
+// interactiveStarter is implemented by the executors that can keep an
+// interpreter running for a REPL session.
+type interactiveStarter interface {
+	startInteractive(name string, lang *LanguageSpec, config ExecutionConfig, stdout io.Writer, stderr io.Writer) (*interactiveProcess, error)
+}
+
+// interactiveProcess is a running interpreter. Wait returns when it exits and
+// is called once; Stop kills it and removes its sandbox.
+type interactiveProcess struct {
+	Stdin io.WriteCloser
+	Wait  func() error
+	Stop  func()
+}
+
+// ReplStreamer delivers what happens in a REPL session to the classroom. The
+// input of a session is published as the "input" stream, so a projected
+// session shows students what the teacher typed.
+type ReplStreamer interface {
+	PublishReplOutput(session *ReplSessionInfo, stream string, chunk string, seq int)
+	PublishReplClosed(session *ReplSessionInfo, reason string)
+}
+
+// ReplSessionInfo describes a long-lived interpreter of one user. A projected
+// session is shown to the whole lecture.
+type ReplSessionInfo struct {
+	ID         string    `json:"session_id"`
+	LectureID  uint      `json:"lecture_id"`
+	UserZCode  string    `json:"user_zcode"`
+	Language   string    `json:"language"`
+	Projected  bool      `json:"projected"`
+	StartedAt  time.Time `json:"started_at"`
+	LastActive time.Time `json:"last_active"`
+}
+
+type ReplSession struct {
+	ReplSessionInfo
+
+	lang    *LanguageSpec
+	config  ExecutionConfig
+	policy  *CodePolicy
+	process *interactiveProcess
+
+	// bytes printed since the last input, bounded by config.OutputLimitKB
+	outputSinceInput int64
+	outputExceeded   bool
+	seq              int
+	closed           bool
+	mutex            sync.Mutex
+}
+
+type ReplManager struct {
+	executor Executor
+	streamer ReplStreamer
+	sessions map[string]*ReplSession
+	mutex    sync.Mutex
+}
+
+var GlobalReplManager *ReplManager
+
+func newReplManager(executor Executor) *ReplManager {
+	rm := &ReplManager{
+		executor: executor,
+		sessions: make(map[string]*ReplSession),
+	}
+	go rm.janitor()
+	return rm
+}
+
+func (rm *ReplManager) SetStreamer(streamer ReplStreamer) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.streamer = streamer
+}
+
+// Open starts a session for the user, closing the one they already have in the
+// lecture. Only teachers may project a session.
+func (rm *ReplManager) Open(lectureID uint, userZCode string, userRole string, language string, projected bool) (*ReplSessionInfo, error) {
+	lang, err := GlobalLanguageRegistry.Get(language)
+	if err != nil {
+		return nil, err
+	}
+	starter, ok := rm.executor.(interactiveStarter)
+	if !ok || len(lang.ReplCmd) == 0 {
+		return nil, ErrReplUnsupported
+	}
+	if projected && userRole != "teacher" {
+		return nil, errors.New("only the teacher can project a session")
+	}
+	profile := ResolveProfile(lang, lectureID, userRole)
+
+	now := time.Now()
+	session := &ReplSession{
+		ReplSessionInfo: ReplSessionInfo{
+			ID:         generateExecutionID(),
+			LectureID:  lectureID,
+			UserZCode:  userZCode,
+			Language:   lang.Name,
+			Projected:  projected,
+			StartedAt:  now,
+			LastActive: now,
+		},
+		lang:   profile.Language,
+		config: profile.Config,
+		policy: profile.Policy,
+	}
+
+	// the slot is taken before the sandbox starts, so concurrent opens respect the cap
+	rm.mutex.Lock()
+	var previous *ReplSession
+	count := 0
+	for _, existing := range rm.sessions {
+		if existing.LectureID != lectureID {
+			continue
+		}
+		if existing.UserZCode == userZCode {
+			previous = existing
+			continue
+		}
+		count++
+	}
+	if count >= MaxReplSessionsPerLecture {
+		rm.mutex.Unlock()
+		return nil, ErrTooManyReplSessions
+	}
+	rm.sessions[session.ID] = session
+	rm.mutex.Unlock()
+
+	if previous != nil {
+		rm.closeSession(previous, ReplClosedReplaced)
+	}
+
+	process, err := starter.startInteractive("zcode_repl_"+session.ID, session.lang, session.config,
+		&replWriter{rm: rm, session: session, stream: "stdout"},
+		&replWriter{rm: rm, session: session, stream: "stderr"})
+	if err != nil {
+		rm.mutex.Lock()
+		delete(rm.sessions, session.ID)
+		rm.mutex.Unlock()
+		return nil, fmt.Errorf("failed to start the interpreter: %v", err)
+	}
+	session.mutex.Lock()
+	session.process = process
+	closedWhileStarting := session.closed
+	session.mutex.Unlock()
+
+	go func() {
+		if err := process.Wait(); err != nil {
+			log.Printf("interactive session %s ended: %v", session.ID, err)
+		}
+		rm.closeSession(session, ReplClosedExited)
+	}()
+	if closedWhileStarting {
+		// the user left or opened another session in the meantime
+		process.Stdin.Close()
+		process.Stop()
+		return nil, errReplSessionNotFound
+	}
+
+	log.Printf("interactive %s session %s opened by %s in lecture %d (projected=%v)", lang.Name, session.ID, userZCode, lectureID, projected)
+	snapshot := session.snapshot()
+	return &snapshot, nil
+}
+
+// checkPolicy applies the code policy to the input, line by line as the
+// interpreter reads it. A line that ends inside a statement is rejected: the
+// interpreter would join it with the next line, which is checked on its own,
+// so "import \" followed by "os" would get past the policy.
+func (s *ReplSession) checkPolicy(input string) error {
+	if s.lang.Validate == nil || s.policy == nil {
+		return nil
+	}
+	violations := make([]PolicyViolation, 0)
+	for i, line := range strings.Split(input, "\n") {
+		if s.lang.InputIncomplete != nil && s.lang.InputIncomplete(line+"\n") {
+			violations = append(violations, PolicyViolation{
+				Line:    i + 1,
+				Rule:    "syntax",
+				Message: "a statement must end on the line it starts in an interactive session",
+			})
+			continue
+		}
+		for _, violation := range s.lang.Validate([]WorkspaceFile{{Path: s.lang.SourceFile, Content: line}}, s.policy) {
+			if violation.Rule != "syntax" && violation.Rule != "size" {
+				violation.File = ""
+				violation.Line = i + 1
+				violations = append(violations, violation)
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Policy: s.policy.Name, Violations: violations}
+	}
+	return nil
+}
+
+// Input sends a line to the owner's interpreter.
+func (rm *ReplManager) Input(sessionID string, userZCode string, input string) error {
+	session, err := rm.get(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserZCode != userZCode {
+		return errors.New("you can not type into this session")
+	}
+	if len(input) > MaxReplInputBytes {
+		return fmt.Errorf("input too long (max %d KB)", MaxReplInputBytes/1024)
+	}
+	if err := session.checkPolicy(input); err != nil {
+		return err
+	}
+
+	session.mutex.Lock()
+	if session.closed || session.process == nil {
+		session.mutex.Unlock()
+		return errReplSessionNotFound
+	}
+	session.LastActive = time.Now()
+	session.outputSinceInput = 0
+	session.seq++
+	seq := session.seq
+	stdin := session.process.Stdin
+	session.mutex.Unlock()
+
+	rm.publishOutput(session, "input", input+"\n", seq)
+	if _, err := io.WriteString(stdin, input+"\n"); err != nil {
+		return fmt.Errorf("the interpreter is not running: %v", err)
+	}
+	return nil
+}
+
+// Close ends a session: the owner may close it, and the teacher any session of the lecture.
+func (rm *ReplManager) Close(sessionID string, userZCode string, isLectureTeacher bool) error {
+	session, err := rm.get(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserZCode != userZCode && !isLectureTeacher {
+		return errors.New("you can not close this session")
+	}
+	rm.closeSession(session, ReplClosedByUser)
+	return nil
+}
+
+// CloseUserSessions ends the sessions of a user who left the lecture.
+func (rm *ReplManager) CloseUserSessions(lectureID uint, userZCode string) {
+	rm.mutex.Lock()
+	sessions := make([]*ReplSession, 0)
+	for _, session := range rm.sessions {
+		if session.LectureID == lectureID && session.UserZCode == userZCode {
+			sessions = append(sessions, session)
+		}
+	}
+	rm.mutex.Unlock()
+
+	for _, session := range sessions {
+		rm.closeSession(session, ReplClosedLeft)
+	}
+}
+
+// List returns the open sessions of a lecture.
+func (rm *ReplManager) List(lectureID uint) []ReplSessionInfo {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	sessions := make([]ReplSessionInfo, 0)
+	for _, session := range rm.sessions {
+		if session.LectureID == lectureID {
+			sessions = append(sessions, session.snapshot())
+		}
+	}
+	return sessions
+}
+
+func (rm *ReplManager) GetStats() map[string]interface{} {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	perLecture := make(map[uint]int)
+	for _, session := range rm.sessions {
+		perLecture[session.LectureID]++
+	}
+	return map[string]interface{}{
+		"open_sessions":            len(rm.sessions),
+		"sessions_per_lecture":     perLecture,
+		"max_sessions_per_lecture": MaxReplSessionsPerLecture,
+		"idle_timeout_seconds":     int(ReplIdleTimeout.Seconds()),
+	}
+}
+
+func (rm *ReplManager) get(sessionID string) (*ReplSession, error) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	session, exists := rm.sessions[sessionID]
+	if !exists {
+		return nil, errReplSessionNotFound
+	}
+	return session, nil
+}
+
+// closeSession stops the interpreter once, whoever asks first.
+func (rm *ReplManager) closeSession(session *ReplSession, reason string) {
+	session.mutex.Lock()
+	if session.closed {
+		session.mutex.Unlock()
+		return
+	}
+	session.closed = true
+	process := session.process
+	session.mutex.Unlock()
+
+	rm.mutex.Lock()
+	delete(rm.sessions, session.ID)
+	streamer := rm.streamer
+	rm.mutex.Unlock()
+
+	if process != nil {
+		process.Stdin.Close()
+		process.Stop()
+	}
+	if streamer != nil {
+		snapshot := session.snapshot()
+		streamer.PublishReplClosed(&snapshot, reason)
+	}
+	log.Printf("interactive session %s of %s closed: %s", session.ID, session.UserZCode, reason)
+}
+
+func (rm *ReplManager) publishOutput(session *ReplSession, stream string, chunk string, seq int) {
+	rm.mutex.Lock()
+	streamer := rm.streamer
+	rm.mutex.Unlock()
+	if streamer != nil {
+		snapshot := session.snapshot()
+		streamer.PublishReplOutput(&snapshot, stream, chunk, seq)
+	}
+}
+
+// janitor closes sessions nobody typed into for ReplIdleTimeout, and every
+// session after ReplMaxLifetime.
+func (rm *ReplManager) janitor() {
+	ticker := time.NewTicker(replJanitorInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		expired := make(map[*ReplSession]string)
+		rm.mutex.Lock()
+		for _, session := range rm.sessions {
+			session.mutex.Lock()
+			if now.Sub(session.StartedAt) > ReplMaxLifetime {
+				expired[session] = ReplClosedLifetime
+			} else if now.Sub(session.LastActive) > ReplIdleTimeout {
+				expired[session] = ReplClosedIdle
+			}
+			session.mutex.Unlock()
+		}
+		rm.mutex.Unlock()
+
+		for session, reason := range expired {
+			rm.closeSession(session, reason)
+		}
+	}
+}
+
+func (s *ReplSession) snapshot() ReplSessionInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ReplSessionInfo
+}
+
+// replWriter publishes the interpreter's output as it arrives. A session that
+// prints more than the output limit after one input is closed.
+type replWriter struct {
+	rm      *ReplManager
+	session *ReplSession
+	stream  string
+}
+
+func (w *replWriter) Write(p []byte) (int, error) {
+	session := w.session
+	session.mutex.Lock()
+	if session.closed || session.outputExceeded {
+		session.mutex.Unlock()
+		return len(p), nil
+	}
+	session.outputSinceInput += int64(len(p))
+	exceeded := session.config.OutputLimitKB > 0 && session.outputSinceInput > int64(session.config.OutputLimitKB)*1024
+	session.outputExceeded = exceeded
+	session.seq++
+	seq := session.seq
+	session.mutex.Unlock()
+
+	if exceeded {
+		w.rm.publishOutput(session, "stderr", "\n[output limit reached]\n", seq)
+		go w.rm.closeSession(session, ReplClosedOutput)
+		return len(p), nil
+	}
+	w.rm.publishOutput(session, w.stream, string(p), seq)
+	return len(p), nil
+}
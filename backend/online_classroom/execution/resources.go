@@ -0,0 +1,89 @@
+package execution
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exit code of a process killed with SIGKILL, which is what the OOM killer sends
+const sigkillExitCode = 137
+
+// cgroupStatsScript prints the container's counters as "key value" lines on
+// both cgroup v2 and v1 hosts.
+const cgroupStatsScript = `if [ -f /sys/fs/cgroup/cpu.stat ]; then
+  grep usage_usec /sys/fs/cgroup/cpu.stat
+  echo "peak_bytes $(cat /sys/fs/cgroup/memory.peak 2>/dev/null)"
+  grep '^oom_kill ' /sys/fs/cgroup/memory.events
+else
+  echo "usage_ns $(cat /sys/fs/cgroup/cpuacct/cpuacct.usage 2>/dev/null || cat /sys/fs/cgroup/cpu,cpuacct/cpuacct.usage 2>/dev/null)"
+  echo "peak_bytes $(cat /sys/fs/cgroup/memory/memory.max_usage_in_bytes 2>/dev/null)"
+  grep '^oom_kill ' /sys/fs/cgroup/memory/memory.oom_control
+fi`
+
+type cgroupStats struct {
+	cpuUsageMicros int64
+	peakBytes      int64
+	oomKills       int64
+}
+
+// readCgroupStats samples the sandbox container's cgroup. The container is
+// shared by all phases, so callers diff two samples to isolate the run phase.
+func readCgroupStats(sb sandbox) (cgroupStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stdout, stderr, _, err := sb.Exec(ctx, []string{"sh", "-c", cgroupStatsScript}, "", nil, nil)
+	if err != nil && stdout == "" {
+		return cgroupStats{}, fmt.Errorf("%v %s", err, strings.TrimSpace(stderr))
+	}
+	return parseCgroupStats(stdout), nil
+}
+
+func parseCgroupStats(output string) cgroupStats {
+	var stats cgroupStats
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "usage_usec":
+			stats.cpuUsageMicros = value
+		case "usage_ns":
+			stats.cpuUsageMicros = value / 1000
+		case "peak_bytes":
+			stats.peakBytes = value
+		case "oom_kill":
+			stats.oomKills = value
+		}
+	}
+	return stats
+}
+
+// applyResourceUsage fills the accounting fields from the samples taken around
+// the run phase and turns a kill by the OOM killer into "memory_exceeded".
+func applyResourceUsage(result *ExecutionResult, before cgroupStats, after cgroupStats, config ExecutionConfig) {
+	result.CPUTimeMS = (after.cpuUsageMicros - before.cpuUsageMicros) / 1000
+	// the peak covers the container's lifetime, for compiled languages it can be the compiler's
+	result.PeakMemoryBytes = after.peakBytes
+
+	limitBytes := int64(config.MemoryLimitMB) * 1024 * 1024
+	nearLimit := limitBytes > 0 && after.peakBytes >= limitBytes*95/100
+	if after.oomKills > before.oomKills || (result.ExitCode == sigkillExitCode && nearLimit) {
+		result.OOMKilled = true
+	}
+
+	if result.OOMKilled && result.Status != "cancelled" && result.Status != "timeout" && result.Status != "output_limit" {
+		result.Status = "memory_exceeded"
+		result.Error = fmt.Sprintf("program was killed after exceeding the memory limit of %d MB", config.MemoryLimitMB)
+	}
+}
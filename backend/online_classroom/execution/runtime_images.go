@@ -0,0 +1,222 @@
+package execution
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// installed packages only change when an admin pushes a new image under the same tag
+const packageListingTTL = time.Hour
+
+var imageNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/:@-]*$`)
+
+// InstalledPackage is a distribution found in a runtime image.
+type InstalledPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// PackageListing tells the students of a lecture what their code can import.
+type PackageListing struct {
+	Language  string                 `json:"language"`
+	Image     string                 `json:"image"`
+	Runtime   *entities.RuntimeImage `json:"runtime,omitempty"` // nil when the class uses the default image
+	Installed []InstalledPackage     `json:"installed"`
+	ListedAt  time.Time              `json:"listed_at"`
+
+	// AllowedImports, when not empty, is the complete list the policy lets through
+	AllowedImports []string `json:"allowed_imports,omitempty"`
+	DeniedImports  []string `json:"denied_imports,omitempty"`
+}
+
+type packageListingEntry struct {
+	packages []InstalledPackage
+	listedAt time.Time
+}
+
+var packageListings = struct {
+	entries map[string]packageListingEntry // by image
+	mutex   sync.Mutex
+}{entries: make(map[string]packageListingEntry)}
+
+// findClassRuntime returns the runtime image the class chose for the language, nil
+// for the default image.
+func findClassRuntime(classID uint, language string) *entities.RuntimeImage {
+	if configs.RuntimeImageApplications == nil {
+		return nil
+	}
+	classRuntime, err := configs.RuntimeImageApplications.FindClassRuntime(classID, language)
+	if err != nil {
+		return nil
+	}
+	runtime, err := configs.RuntimeImageApplications.FindRuntimeImageByID(classRuntime.RuntimeImageID)
+	if err != nil {
+		log.Printf("WARNING: class %d uses missing runtime image %d", classID, classRuntime.RuntimeImageID)
+		return nil
+	}
+	if runtime.Language != language {
+		return nil
+	}
+	return runtime
+}
+
+// ConfiguredImages lists the default image of every language and every runtime
+// image an admin defined.
+func ConfiguredImages() []string {
+	seen := make(map[string]bool)
+	images := make([]string, 0)
+	add := func(image string) {
+		if image != "" && !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	for _, lang := range GlobalLanguageRegistry.List() {
+		add(lang.Image)
+	}
+	if configs.RuntimeImageApplications != nil {
+		runtimes, err := configs.RuntimeImageApplications.FindAllRuntimeImages()
+		if err != nil {
+			log.Printf("WARNING: failed to load runtime images: %v", err)
+		}
+		for _, runtime := range runtimes {
+			add(runtime.Image)
+		}
+	}
+	sort.Strings(images)
+	return images
+}
+
+// CheckExecutionBackend verifies that the backend can run code, with every
+// configured image pulled. It runs once the database is up, so runtime images
+// defined by admins are checked too.
+func CheckExecutionBackend() {
+	checker, ok := GlobalExecutionManager.executor.(dockerChecker)
+	if !ok {
+		return
+	}
+	if err := checker.CheckDockerAvailable(); err != nil {
+		log.Printf("WARNING: Docker environment check failed: %v", err)
+		log.Printf("Code execution may not work properly")
+	} else {
+		log.Printf("Docker environment check passed")
+	}
+}
+
+// ValidateRuntimeImage checks a runtime image before it is stored.
+func ValidateRuntimeImage(runtime *entities.RuntimeImage) error {
+	if strings.TrimSpace(runtime.Name) == "" {
+		return fmt.Errorf("runtime name is required")
+	}
+	if _, err := GlobalLanguageRegistry.Get(runtime.Language); err != nil {
+		return err
+	}
+	if !imageNamePattern.MatchString(runtime.Image) {
+		return fmt.Errorf("invalid image: %s", runtime.Image)
+	}
+	for _, name := range SplitAllowedPackages(runtime.Packages) {
+		if !packageNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid package name: %s", name)
+		}
+	}
+	return nil
+}
+
+// ListPackages reports what a run of role in the lecture can use: the packages
+// installed in the image the class runs and the imports its policy allows.
+func ListPackages(lang *LanguageSpec, lectureID uint, role string) (*PackageListing, error) {
+	profile := ResolveProfile(lang, lectureID, role)
+	listing := &PackageListing{
+		Language:       lang.Name,
+		Image:          profile.Language.Image,
+		Runtime:        profile.Runtime,
+		Installed:      make([]InstalledPackage, 0),
+		AllowedImports: profile.Policy.AllowedImports,
+		DeniedImports:  profile.Policy.DeniedImports,
+	}
+	if profile.Language.PackageListCode == "" {
+		listing.ListedAt = time.Now()
+		return listing, nil
+	}
+
+	packages, listedAt, err := installedPackages(profile.Language)
+	if err != nil {
+		return nil, err
+	}
+	listing.Installed = packages
+	listing.ListedAt = listedAt
+	return listing, nil
+}
+
+// installedPackages runs the language's listing program in the image, the result
+// is kept for packageListingTTL.
+func installedPackages(lang *LanguageSpec) ([]InstalledPackage, time.Time, error) {
+	packageListings.mutex.Lock()
+	entry, exists := packageListings.entries[lang.Image]
+	packageListings.mutex.Unlock()
+	if exists && time.Since(entry.listedAt) < packageListingTTL {
+		return entry.packages, entry.listedAt, nil
+	}
+
+	config := lang.DefaultConfig
+	if config.TimeoutSeconds < 30 {
+		config.TimeoutSeconds = 30
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.TimeoutSeconds+30)*time.Second)
+	defer cancel()
+	result, err := GlobalExecutionManager.executor.Execute(&ExecutionTask{
+		Code:       lang.PackageListCode,
+		Files:      []WorkspaceFile{{Path: lang.SourceFile, Content: lang.PackageListCode}},
+		Entrypoint: lang.SourceFile,
+		Language:   lang,
+		Config:     config,
+		// our own program, the class policy does not apply
+		Policy:  &CodePolicy{Name: "package listing"},
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to list the packages of %s: %v", lang.Image, err)
+	}
+	if result.Status != "completed" {
+		return nil, time.Time{}, fmt.Errorf("failed to list the packages of %s: %s %s", lang.Image, result.Status, result.Error)
+	}
+
+	packages := parsePackageList(result.Stdout)
+	listedAt := time.Now()
+	packageListings.mutex.Lock()
+	packageListings.entries[lang.Image] = packageListingEntry{packages: packages, listedAt: listedAt}
+	packageListings.mutex.Unlock()
+	return packages, listedAt, nil
+}
+
+func parsePackageList(output string) []InstalledPackage {
+	seen := make(map[string]bool)
+	packages := make([]InstalledPackage, 0)
+	for _, line := range strings.Split(output, "\n") {
+		name, version, found := strings.Cut(strings.TrimSpace(line), "==")
+		if !found || name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		packages = append(packages, InstalledPackage{Name: name, Version: version})
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return strings.ToLower(packages[i].Name) < strings.ToLower(packages[j].Name)
+	})
+	return packages
+}
+
+// forgetPackageListing drops the cached listing, after an admin changed the image.
+func forgetPackageListing(image string) {
+	packageListings.mutex.Lock()
+	delete(packageListings.entries, image)
+	packageListings.mutex.Unlock()
+}
@@ -0,0 +1,173 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// sandbox is a started, idle container the pipeline phases exec into. The CLI
+// and the Engine API backends differ only in how they start it and run commands.
+type sandbox interface {
+	// Exec runs command in the container. Like exec.Cmd it returns an error for a
+	// non-zero exit code, and exit code -1 when ctx ended the command.
+	Exec(ctx context.Context, command []string, stdin string, onOutput func(stream string, chunk string), budget *outputBudget) (string, string, int, error)
+
+	// StopProcesses kills everything the program started but keeps the container.
+	StopProcesses()
+}
+
+// runPipeline runs the phases of an execution in a started sandbox: the
+// optional compile step, the program itself, and the collection of its
+// resource usage and artifacts.
+func runPipeline(sb sandbox, task *ExecutionTask, result *ExecutionResult) {
+	lang := task.Language
+	config := task.Config
+
+	if lang.IsCompiled() && !compilePhase(sb, task, result) {
+		return
+	}
+
+	before, beforeErr := readCgroupStats(sb)
+	runPhase(sb, task, result)
+	after, afterErr := readCgroupStats(sb)
+	if beforeErr == nil && afterErr == nil {
+		applyResourceUsage(result, before, after, config)
+	} else {
+		log.Printf("WARNING: failed to read resource usage of %s: %v %v", result.ID, beforeErr, afterErr)
+	}
+
+	if result.Status != "cancelled" {
+		artifacts, err := collectArtifacts(sb, config.ArtifactLimitMB)
+		if err != nil {
+			log.Printf("WARNING: failed to collect artifacts of %s: %v", result.ID, err)
+		}
+		result.Artifacts = artifacts
+	}
+}
+
+// compilePhase runs the compile step and reports whether the program can be run.
+func compilePhase(sb sandbox, task *ExecutionTask, result *ExecutionResult) bool {
+	lang := task.Language
+	config := task.Config
+
+	compileStart := time.Now()
+	compileCtx, compileCancel := context.WithTimeout(task.context(), time.Duration(config.CompileTimeoutSeconds)*time.Second)
+	compileScript := "mkdir -p /tmp/build && " + strings.Join(expandCommand(lang.CompileCmd, task.Entrypoint), " ")
+	// compiler output is only truncated, the compiler stops by itself
+	compileBudget := newOutputBudget(config.OutputLimitKB, nil)
+	compileStdout, compileStderr, compileExit, err := sb.Exec(compileCtx, []string{"sh", "-c", compileScript}, "", compileOutputSink(task.OnOutput), compileBudget)
+	compileTimedOut := compileCtx.Err() == context.DeadlineExceeded
+	compileCancel()
+
+	result.CompileDurationMS = time.Since(compileStart).Milliseconds()
+	result.CompileOutput = strings.TrimSpace(compileStdout + compileStderr)
+	result.Diagnostics = parseDiagnostics(result.CompileOutput)
+
+	if task.context().Err() != nil {
+		result.Status = "cancelled"
+		result.ExitCode = -1
+		result.Error = ErrExecutionCancelled.Error()
+		return false
+	}
+	if compileTimedOut {
+		result.CompileStatus = "timeout"
+		result.Status = "compile_error"
+		result.TimedOut = true
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("compilation timeout after %d seconds", config.CompileTimeoutSeconds)
+		return false
+	}
+	if err != nil {
+		result.CompileStatus = "failed"
+		result.Status = "compile_error"
+		result.ExitCode = compileExit
+		result.Error = "compilation failed"
+		return false
+	}
+	result.CompileStatus = "success"
+	return true
+}
+
+func runPhase(sb sandbox, task *ExecutionTask, result *ExecutionResult) {
+	lang := task.Language
+	config := task.Config
+
+	runCtx, runCancel := context.WithTimeout(task.context(), time.Duration(config.TimeoutSeconds)*time.Second)
+	defer runCancel()
+	budget := newOutputBudget(config.OutputLimitKB, func() {
+		go sb.StopProcesses()
+	})
+	// always attach stdin: an empty pipe gives the program an immediate EOF instead of a hang
+	stdout, stderr, exitCode, err := sb.Exec(runCtx, expandCommand(lang.RunCmd, task.Entrypoint), task.Stdin, task.OnOutput, budget)
+	if runCtx.Err() != nil {
+		// ending the exec client does not end the program inside the container
+		sb.StopProcesses()
+	}
+
+	result.Output = joinOutput(stdout, stderr)
+	result.Stdout = stdout
+	result.ExitCode = exitCode
+	result.OutputBytes = int64(len(stdout) + len(stderr))
+	if budget != nil {
+		result.OutputBytes = budget.Printed()
+	}
+	defer markStdinExhausted(result, lang)
+	if task.context().Err() != nil {
+		result.Status = "cancelled"
+		result.ExitCode = -1
+		result.Error = ErrExecutionCancelled.Error()
+		return
+	}
+	if budget.Exceeded() {
+		result.Status = "output_limit"
+		result.OutputTruncated = true
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("program was stopped after printing more than %d KB", config.OutputLimitKB)
+		return
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		result.Status = "timeout"
+		result.TimedOut = true
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("execution timeout after %d seconds", config.TimeoutSeconds)
+		return
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return
+	}
+	result.Status = "completed"
+}
+
+// sandboxTmpfs lists the writable mounts of a sandbox, everything else is read-only.
+func sandboxTmpfs(lang *LanguageSpec, config ExecutionConfig) map[string]string {
+	mounts := make(map[string]string)
+	if lang.IsCompiled() {
+		// compilers need a writable scratch dir, and the binary has to be executable
+		mounts["/tmp"] = "rw,exec,nosuid,size=128m"
+	} else {
+		// libraries such as matplotlib need somewhere to keep their caches
+		mounts["/tmp"] = "rw,noexec,nosuid,size=16m"
+	}
+	if config.ArtifactLimitMB > 0 {
+		// the only place a program can leave files behind, collected after the run
+		mounts[ArtifactDir] = fmt.Sprintf("rw,noexec,nosuid,size=%dm,mode=1777", config.ArtifactLimitMB)
+	}
+	return mounts
+}
+
+func sandboxEnv(lang *LanguageSpec, config ExecutionConfig) []string {
+	env := make([]string, 0, len(lang.Env)+3)
+	if config.ArtifactLimitMB > 0 {
+		env = append(env, "OUTPUT_DIR="+ArtifactDir, "MPLBACKEND=Agg", "MPLCONFIGDIR=/tmp/matplotlib")
+	}
+	return append(env, lang.Env...)
+}
+
+// stopProcessesCommand kills every process but the container's init, which is
+// what keeps it alive. kill -1 skips the calling shell itself.
+var stopProcessesCommand = []string{"sh", "-c", "kill -9 -1"}
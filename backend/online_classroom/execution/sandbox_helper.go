@@ -0,0 +1,122 @@
+package execution
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// sandboxHelperArg as the first argument turns the backend binary into the
+// launcher of a local program: it sets the rlimits on itself and execs the
+// interpreter, which inherits them. Go cannot set rlimits on a child between
+// fork and exec, so this is done by a re-executed copy of the binary.
+const sandboxHelperArg = "__zcode_sandbox__"
+
+// RLIMIT_NPROC, which the syscall package does not export on every platform
+const rlimitNproc = 6
+
+// The helper runs during variable initialization, before any init function of
+// this package, so a launcher never starts the execution manager or checks docker.
+var _ = runSandboxHelper()
+
+type sandboxLimits struct {
+	cpuSeconds        int
+	addressSpaceBytes int64 // 0 leaves it unlimited
+	fileSizeBytes     int64
+	processes         int // on top of the user's current processes
+}
+
+// args is the launcher's command line up to the program's own command.
+func (sl sandboxLimits) args() []string {
+	return []string{
+		sandboxHelperArg,
+		fmt.Sprintf("cpu=%d", sl.cpuSeconds),
+		fmt.Sprintf("as=%d", sl.addressSpaceBytes),
+		fmt.Sprintf("fsize=%d", sl.fileSizeBytes),
+		fmt.Sprintf("nproc=%d", sl.processes),
+		"--",
+	}
+}
+
+// runSandboxHelper does nothing in the backend itself. In a launcher it never
+// returns: it either becomes the program or exits with 127.
+func runSandboxHelper() bool {
+	if len(os.Args) < 2 || os.Args[1] != sandboxHelperArg {
+		return false
+	}
+
+	command := make([]string, 0)
+	limits := make(map[string]uint64)
+	for i, arg := range os.Args[2:] {
+		if arg == "--" {
+			command = os.Args[i+3:]
+			break
+		}
+		key, value, _ := strings.Cut(arg, "=")
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			sandboxHelperFail(fmt.Errorf("invalid limit %q", arg))
+		}
+		limits[key] = parsed
+	}
+	if len(command) == 0 {
+		sandboxHelperFail(fmt.Errorf("no command"))
+	}
+
+	// SIGXCPU at the soft limit, SIGKILL a second later if it is ignored
+	setLimit(syscall.RLIMIT_CPU, limits["cpu"], limits["cpu"]+1)
+	if limits["as"] > 0 {
+		setLimit(syscall.RLIMIT_AS, limits["as"], limits["as"])
+	}
+	setLimit(syscall.RLIMIT_FSIZE, limits["fsize"], limits["fsize"])
+	setLimit(syscall.RLIMIT_CORE, 0, 0)
+	// the process limit counts every thread of the user, not only this program's
+	userTasks := uint64(userTaskCount(os.Getuid()))
+	setLimit(rlimitNproc, userTasks+limits["nproc"], userTasks+limits["nproc"])
+
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		sandboxHelperFail(err)
+	}
+	sandboxHelperFail(syscall.Exec(path, command, os.Environ()))
+	return true
+}
+
+func setLimit(resource int, soft uint64, hard uint64) {
+	if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: soft, Max: hard}); err != nil {
+		sandboxHelperFail(fmt.Errorf("setrlimit %d: %v", resource, err))
+	}
+}
+
+func sandboxHelperFail(err error) {
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(127)
+}
+
+// userTaskCount counts the processes and threads of uid, which is what RLIMIT_NPROC limits.
+func userTaskCount(uid int) int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		info, err := os.Stat("/proc/" + entry.Name())
+		if err != nil {
+			continue
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != uid {
+			continue
+		}
+		if tasks, err := os.ReadDir("/proc/" + entry.Name() + "/task"); err == nil {
+			count += len(tasks)
+		}
+	}
+	return count
+}
@@ -0,0 +1,183 @@
+package execution
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	streamChunkBytes    = 4096
+	streamFlushInterval = 100 * time.Millisecond
+)
+
+// ExecutionStreamer receives live output of running executions. It is implemented
+// by the websocket manager and injected with SetStreamer to avoid an import cycle.
+type ExecutionStreamer interface {
+	PublishOutput(result *ExecutionResult, stream string, chunk string, seq int)
+	PublishFinished(result *ExecutionResult)
+	// PublishCancel asks the node running the execution to stop it
+	PublishCancel(result *ExecutionResult)
+}
+
+// outputBudget is the output allowance shared by the stdout and stderr writers
+// of one command. onExceeded runs once, when the first byte over the limit arrives.
+type outputBudget struct {
+	limit      int
+	used       int
+	printed    int64
+	exceeded   bool
+	onExceeded func()
+	mutex      sync.Mutex
+}
+
+func newOutputBudget(limitKB int, onExceeded func()) *outputBudget {
+	if limitKB <= 0 {
+		return nil
+	}
+	return &outputBudget{limit: limitKB * 1024, onExceeded: onExceeded}
+}
+
+// take reserves up to n bytes and returns how many fit in the head.
+func (ob *outputBudget) take(n int) int {
+	ob.mutex.Lock()
+	ob.printed += int64(n)
+	granted := n
+	if remaining := ob.limit - ob.used; granted > remaining {
+		granted = remaining
+	}
+	ob.used += granted
+	fire := granted < n && !ob.exceeded
+	if fire {
+		ob.exceeded = true
+	}
+	ob.mutex.Unlock()
+
+	if fire && ob.onExceeded != nil {
+		ob.onExceeded()
+	}
+	return granted
+}
+
+// Printed is the number of bytes the command printed, kept or not.
+func (ob *outputBudget) Printed() int64 {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	return ob.printed
+}
+
+func (ob *outputBudget) Exceeded() bool {
+	if ob == nil {
+		return false
+	}
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	return ob.exceeded
+}
+
+// streamWriter keeps the output like a bytes.Buffer and forwards it in
+// coalesced chunks, so a tight print loop does not flood the websocket. With a
+// budget, only the head fits in buffer and the last tailBytes are kept in tail.
+type streamWriter struct {
+	stream   string
+	emit     func(stream string, chunk string)
+	buffer   bytes.Buffer
+	pending  bytes.Buffer
+	lastEmit time.Time
+	mutex    sync.Mutex
+
+	budget    *outputBudget
+	tail      []byte
+	tailBytes int
+	dropped   int64 // bytes neither in buffer nor in tail
+}
+
+func newStreamWriter(stream string, emit func(stream string, chunk string)) *streamWriter {
+	return &streamWriter{
+		stream:   stream,
+		emit:     emit,
+		lastEmit: time.Now(),
+	}
+}
+
+func newLimitedStreamWriter(stream string, emit func(stream string, chunk string), budget *outputBudget) *streamWriter {
+	sw := newStreamWriter(stream, emit)
+	if budget != nil {
+		sw.budget = budget
+		sw.tailBytes = budget.limit / 4
+	}
+	return sw
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	head := p
+	if sw.budget != nil {
+		head = p[:sw.budget.take(len(p))]
+		if rest := p[len(head):]; len(rest) > 0 {
+			sw.keepTailLocked(rest)
+		}
+	}
+
+	sw.buffer.Write(head)
+	if sw.emit == nil || len(head) == 0 {
+		return len(p), nil
+	}
+
+	sw.pending.Write(head)
+	if len(head) < len(p) {
+		// the live view stops here, the tail arrives with the final result
+		sw.pending.WriteString("\n[output limit reached]\n")
+		sw.flushLocked()
+		return len(p), nil
+	}
+	if sw.pending.Len() >= streamChunkBytes || time.Since(sw.lastEmit) >= streamFlushInterval {
+		sw.flushLocked()
+	}
+	return len(p), nil
+}
+
+func (sw *streamWriter) keepTailLocked(p []byte) {
+	sw.tail = append(sw.tail, p...)
+	if overflow := len(sw.tail) - sw.tailBytes; overflow > 0 {
+		sw.dropped += int64(overflow)
+		sw.tail = append(sw.tail[:0], sw.tail[overflow:]...)
+	}
+}
+
+func (sw *streamWriter) Flush() {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	sw.flushLocked()
+}
+
+func (sw *streamWriter) flushLocked() {
+	if sw.emit == nil || sw.pending.Len() == 0 {
+		return
+	}
+	sw.emit(sw.stream, sw.pending.String())
+	sw.pending.Reset()
+	sw.lastEmit = time.Now()
+}
+
+// String returns the output, with a marker between head and tail when bytes were dropped.
+func (sw *streamWriter) String() string {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	if len(sw.tail) == 0 {
+		return sw.buffer.String()
+	}
+	if sw.dropped == 0 {
+		return sw.buffer.String() + string(sw.tail)
+	}
+	return fmt.Sprintf("%s\n... [%d bytes truncated] ...\n%s", sw.buffer.String(), sw.dropped, sw.tail)
+}
+
+func (sw *streamWriter) Len() int {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	return sw.buffer.Len()
+}
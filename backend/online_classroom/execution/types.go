@@ -1,17 +1,31 @@
 package execution
 
 import (
+	"context"
+	"errors"
 	"time"
 )
 
 type ExecutionRequest struct {
 	LectureID   uint   `json:"lecture_id" binding:"required"`
-	UserZCode   string `json:"user_zcode" binding:"required"`
-	Code        string `json:"code" binding:"required"`
+	UserZCode   string `json:"user_zcode"` // set from the token by ExecuteCodeHandler
+	Code        string `json:"code"`       // single-file programs; ignored when Files is set
 	Language    string `json:"language" binding:"required"`
 	DocumentKey string `json:"document_key"`
+	Stdin       string `json:"stdin"`
+	Async       bool   `json:"async"`
+
+	// multi-file projects: the whole tree is mounted read-only at /app
+	Files      []WorkspaceFile `json:"files"`
+	Entrypoint string          `json:"entrypoint"` // defaults to the language's source file, e.g. main.py
+
+	// Grading is set by the grader: its runs stay out of the history, the
+	// statistics and the classroom stream, they would show the hidden test cases
+	Grading bool `json:"-"`
 }
 
+const MaxStdinBytes = 64 * 1024
+
 type ExecutionResult struct {
 	ID         string    `json:"id"`
 	LectureID  uint      `json:"lecture_id"`
@@ -19,34 +33,108 @@ type ExecutionResult struct {
 	Code       string    `json:"code"`
 	Language   string    `json:"language"`
 	Output     string    `json:"output"`
+	Stdout     string    `json:"-"`
 	Error      string    `json:"error,omitempty"`
 	ExitCode   int       `json:"exit_code"`
 	DurationMS int64     `json:"duration_ms"`
 	ExecutedAt time.Time `json:"executed_at"`
-	Status     string    `json:"status"` // "running", "completed", "failed", "timeout"
+	// "queued", "running", "completed", "failed", "compile_error", "cancelled", or why the
+	// program was killed: "timeout", "memory_exceeded", "output_limit"
+	Status string `json:"status"`
+
+	QueuedAt      time.Time `json:"queued_at"`
+	QueuePosition int       `json:"queue_position,omitempty"`
+
+	StdinExhausted bool `json:"stdin_exhausted,omitempty"`
+
+	CompileStatus     string              `json:"compile_status,omitempty"` // "success", "failed", "timeout"
+	CompileOutput     string              `json:"compile_output,omitempty"`
+	CompileDurationMS int64               `json:"compile_duration_ms,omitempty"`
+	Diagnostics       []CompileDiagnostic `json:"diagnostics,omitempty"`
+
+	Violations []PolicyViolation `json:"violations,omitempty"`
+
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+
+	// OutputTruncated is set when the program printed more than OutputLimitKB. Output
+	// then keeps the head and the tail of each stream, OutputBytes is what was printed.
+	OutputTruncated bool  `json:"output_truncated,omitempty"`
+	OutputBytes     int64 `json:"output_bytes"`
+
+	// resource usage of the run phase, read from the container's cgroup
+	PeakMemoryBytes int64 `json:"peak_memory_bytes"`
+	CPUTimeMS       int64 `json:"cpu_time_ms"`
+	OOMKilled       bool  `json:"oom_killed"`
+	TimedOut        bool  `json:"timed_out"`
+
+	grading bool
+}
+
+type CompileDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // "error", "warning", "note"
+	Message  string `json:"message"`
 }
 
 type ExecutionConfig struct {
-	TimeoutSeconds int     `json:"timeout_seconds"`
-	MemoryLimitMB  int     `json:"memory_limit_mb"`
-	CPULimit       float64 `json:"cpu_limit"`
-	NetworkAccess  bool    `json:"network_access"`
+	TimeoutSeconds        int     `json:"timeout_seconds"`
+	CompileTimeoutSeconds int     `json:"compile_timeout_seconds"`
+	MemoryLimitMB         int     `json:"memory_limit_mb"`
+	CPULimit              float64 `json:"cpu_limit"`
+	NetworkAccess         bool    `json:"network_access"`
+	ArtifactLimitMB       int     `json:"artifact_limit_mb"` // size of the writable /output dir, 0 disables it
+	OutputLimitKB         int     `json:"output_limit_kb"`   // stdout and stderr together, 0 means unlimited
 }
 
 var DefaultExecutionConfig = ExecutionConfig{
-	TimeoutSeconds: 10,
-	MemoryLimitMB:  128,
-	CPULimit:       0.5,
-	NetworkAccess:  false,
+	TimeoutSeconds:        10,
+	CompileTimeoutSeconds: 20,
+	MemoryLimitMB:         128,
+	CPULimit:              0.5,
+	NetworkAccess:         false,
+	ArtifactLimitMB:       8,
+	OutputLimitKB:         256,
 }
 
 var TeacherExecutionConfig = ExecutionConfig{
-	TimeoutSeconds: 30,
-	MemoryLimitMB:  256,
-	CPULimit:       1.0,
-	NetworkAccess:  false,
+	TimeoutSeconds:        30,
+	CompileTimeoutSeconds: 30,
+	MemoryLimitMB:         256,
+	CPULimit:              1.0,
+	NetworkAccess:         false,
+	ArtifactLimitMB:       32,
+	OutputLimitKB:         1024,
+}
+
+var ErrExecutionCancelled = errors.New("execution cancelled")
+
+type ExecutionTask struct {
+	Code       string // content of the entrypoint
+	Files      []WorkspaceFile
+	Entrypoint string
+	Stdin      string
+	Language   *LanguageSpec
+	Config     ExecutionConfig
+
+	// Policy is the code policy of the lecture's class, DefaultCodePolicy when nil
+	Policy *CodePolicy
+
+	// Context is cancelled when somebody stops the execution
+	Context context.Context
+
+	// OnOutput, when set, receives output chunks while the program is still running
+	OnOutput func(stream string, chunk string)
+}
+
+func (t *ExecutionTask) context() context.Context {
+	if t.Context == nil {
+		return context.Background()
+	}
+	return t.Context
 }
 
 type Executor interface {
-	Execute(code string, config ExecutionConfig) (*ExecutionResult, error)
+	Execute(task *ExecutionTask) (*ExecutionResult, error)
 }
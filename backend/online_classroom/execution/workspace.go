@@ -0,0 +1,165 @@
+package execution
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	MaxWorkspaceFiles = 64
+	MaxWorkspaceBytes = 1024 * 1024 // sources and data files together
+)
+
+type WorkspaceFile struct {
+	Path    string `json:"path" binding:"required"` // relative, e.g. "utils/helpers.py" or "data/scores.csv"
+	Content string `json:"content"`
+
+	// Trusted files are written by the server, e.g. the grader's test harness,
+	// and are not held to the code policy
+	Trusted bool `json:"-"`
+}
+
+type WorkspaceError struct {
+	message string
+}
+
+func (e *WorkspaceError) Error() string {
+	return e.message
+}
+
+// no spaces: the compile command is run by sh, with the paths expanded into it
+var workspacePathPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-/]+$`)
+
+// NormalizeWorkspace turns a request into the file tree to mount at /app. A
+// request without files is the single-file case: Code becomes lang.SourceFile.
+func NormalizeWorkspace(req ExecutionRequest, lang *LanguageSpec) ([]WorkspaceFile, string, error) {
+	if len(req.Files) == 0 {
+		if req.Code == "" {
+			return nil, "", &WorkspaceError{"code cannot be empty"}
+		}
+		return []WorkspaceFile{{Path: lang.SourceFile, Content: req.Code}}, lang.SourceFile, nil
+	}
+
+	if len(req.Files) > MaxWorkspaceFiles {
+		return nil, "", &WorkspaceError{fmt.Sprintf("too many files (max %d)", MaxWorkspaceFiles)}
+	}
+
+	files := make([]WorkspaceFile, 0, len(req.Files))
+	seen := make(map[string]bool)
+	totalBytes := 0
+	for _, file := range req.Files {
+		cleanPath, err := cleanWorkspacePath(file.Path)
+		if err != nil {
+			return nil, "", err
+		}
+		if seen[cleanPath] {
+			return nil, "", &WorkspaceError{fmt.Sprintf("duplicate file: %s", cleanPath)}
+		}
+		seen[cleanPath] = true
+		totalBytes += len(file.Content)
+		files = append(files, WorkspaceFile{Path: cleanPath, Content: file.Content, Trusted: file.Trusted})
+	}
+	if totalBytes > MaxWorkspaceBytes {
+		return nil, "", &WorkspaceError{fmt.Sprintf("workspace too large (max %d KB)", MaxWorkspaceBytes/1024)}
+	}
+
+	entrypoint := req.Entrypoint
+	if entrypoint == "" {
+		entrypoint = lang.SourceFile
+	}
+	entrypoint, err := cleanWorkspacePath(entrypoint)
+	if err != nil {
+		return nil, "", err
+	}
+	if !seen[entrypoint] {
+		return nil, "", &WorkspaceError{fmt.Sprintf("entrypoint %s is not in the workspace", entrypoint)}
+	}
+	if path.Ext(entrypoint) != path.Ext(lang.SourceFile) {
+		return nil, "", &WorkspaceError{fmt.Sprintf("entrypoint must be a %s file", path.Ext(lang.SourceFile))}
+	}
+	return files, entrypoint, nil
+}
+
+func cleanWorkspacePath(filePath string) (string, error) {
+	if filePath == "" || !workspacePathPattern.MatchString(filePath) || strings.HasPrefix(filePath, "/") {
+		return "", &WorkspaceError{fmt.Sprintf("invalid file path: %q", filePath)}
+	}
+	cleanPath := path.Clean(filePath)
+	if cleanPath == "." || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+		return "", &WorkspaceError{fmt.Sprintf("invalid file path: %q", filePath)}
+	}
+	return cleanPath, nil
+}
+
+func workspaceEntryCode(files []WorkspaceFile, entrypoint string) string {
+	for _, file := range files {
+		if file.Path == entrypoint {
+			return file.Content
+		}
+	}
+	return ""
+}
+
+// createTempWorkspace writes the file tree under /host/tmp, which the docker
+// daemon sees as /tmp, and returns the directory.
+func (de *DockerExecutor) createTempWorkspace(files []WorkspaceFile, lang *LanguageSpec) (string, error) {
+	tempDir := "/host/tmp"
+	workspaceDir := filepath.Join(tempDir, fmt.Sprintf("%s_ws_%s", lang.Name, generateExecutionID()))
+
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		log.Printf("ERROR: MkdirAll failed: %v", err)
+		return "", err
+	}
+	// the container runs as nobody, the tree must stay world-readable
+	os.Chmod(workspaceDir, 0755)
+
+	if err := writeWorkspace(workspaceDir, files); err != nil {
+		os.RemoveAll(workspaceDir)
+		return "", err
+	}
+	return workspaceDir, nil
+}
+
+// writeWorkspace writes the file tree below root, world-readable.
+func writeWorkspace(root string, files []WorkspaceFile) error {
+	for _, file := range files {
+		filePath := filepath.Join(root, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filePath, []byte(file.Content), 0644); err != nil {
+			log.Printf("ERROR: WriteFile failed: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// expandCommand fills the entrypoint placeholders of a language command:
+// {entry} is the entrypoint path inside the container, {entry_dir} its
+// directory and {main_class} the Java class name derived from it.
+func expandCommand(command []string, entrypoint string) []string {
+	return expandCommandAt(command, "/app", entrypoint)
+}
+
+// expandCommandAt expands the placeholders for a workspace rooted at root.
+func expandCommandAt(command []string, root string, entrypoint string) []string {
+	entry := path.Join(root, entrypoint)
+	mainClass := strings.ReplaceAll(strings.TrimSuffix(entrypoint, path.Ext(entrypoint)), "/", ".")
+	replacer := strings.NewReplacer(
+		"{entry_dir}", path.Dir(entry),
+		"{entry}", entry,
+		"{main_class}", mainClass,
+	)
+
+	expanded := make([]string, len(command))
+	for i, arg := range command {
+		expanded[i] = replacer.Replace(arg)
+	}
+	return expanded
+}
@@ -0,0 +1,191 @@
+package grading
+
+import (
+	"MScProject/core_app/domain/entities"
+	"MScProject/online_classroom/execution"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxDiffLines = 20
+
+// GradeSubmission runs the code once per test case through the shared ExecutionManager.
+// A case with TestCode runs it in a harness that imports the student's code and
+// passes when the harness gets to its end; a case with ExpectedOutput must also
+// match stdout.
+func GradeSubmission(exercise *entities.Exercise, testCases []*entities.ExerciseTestCase, code string, userZCode string, userRole string) *GradeReport {
+	startTime := time.Now()
+	report := &GradeReport{
+		ExerciseID: exercise.ID,
+		LectureID:  exercise.LectureID,
+		UserZCode:  userZCode,
+		TotalCount: len(testCases),
+		GradedAt:   startTime,
+		Cases:      make([]*CaseResult, 0, len(testCases)),
+	}
+
+	totalWeight := 0
+	earnedWeight := 0
+	compileFailed := ""
+
+	for _, testCase := range testCases {
+		caseResult := &CaseResult{
+			TestCaseID:     testCase.ID,
+			Name:           testCase.Name,
+			Hidden:         testCase.Hidden,
+			Weight:         testCase.Weight,
+			Stdin:          testCase.Stdin,
+			ExpectedOutput: testCase.ExpectedOutput,
+		}
+		totalWeight += testCase.Weight
+		report.Cases = append(report.Cases, caseResult)
+
+		// the source is the same for every output-only case, so one compile error fails them all
+		if compileFailed != "" && testCase.TestCode == "" {
+			caseResult.Status = "compile_error"
+			caseResult.Error = compileFailed
+			continue
+		}
+
+		req := execution.ExecutionRequest{
+			LectureID: exercise.LectureID,
+			UserZCode: userZCode,
+			Code:      code,
+			Language:  exercise.Language,
+			Stdin:     testCase.Stdin,
+			Grading:   true,
+		}
+		sentinel := ""
+		if testCase.TestCode != "" {
+			harness, ok := testHarnesses[exercise.Language]
+			if !ok {
+				caseResult.Status = "failed"
+				caseResult.Error = "test code is not supported for " + exercise.Language
+				continue
+			}
+			sentinel = newSentinel()
+			req.Files, req.Entrypoint = harness(code, testCase.TestCode, sentinel)
+		}
+
+		result, err := execution.GlobalExecutionManager.ExecuteCode(req, userRole)
+		if err != nil {
+			caseResult.Status = "failed"
+			caseResult.Error = err.Error()
+			continue
+		}
+
+		caseResult.Status = result.Status
+		caseResult.Error = result.Error
+		caseResult.ActualOutput = result.Stdout
+		caseResult.DurationMS = result.DurationMS
+
+		if result.Status == "compile_error" {
+			caseResult.Error = result.CompileOutput
+			if testCase.TestCode == "" {
+				compileFailed = result.CompileOutput
+			}
+			continue
+		}
+		if result.Status != "completed" || result.ExitCode != 0 {
+			if caseResult.Error == "" {
+				caseResult.Error = result.Output
+			}
+			continue
+		}
+
+		stdout := result.Stdout
+		if sentinel != "" {
+			var finished bool
+			stdout, finished = cutSentinel(result.Stdout, sentinel)
+			caseResult.ActualOutput = stdout
+			if !finished {
+				caseResult.Error = "the program exited before the tests finished"
+				continue
+			}
+		}
+
+		if testCase.ExpectedOutput != "" {
+			caseResult.Diff = diffOutput(testCase.ExpectedOutput, stdout)
+			caseResult.Passed = len(caseResult.Diff) == 0
+		} else {
+			caseResult.Passed = true
+		}
+
+		if caseResult.Passed {
+			report.PassedCount++
+			earnedWeight += testCase.Weight
+		}
+	}
+
+	if totalWeight > 0 {
+		report.Score = float64(earnedWeight) * 100 / float64(totalWeight)
+	}
+	report.DurationMS = time.Since(startTime).Milliseconds()
+
+	return report
+}
+
+// StudentView hides the inputs and outputs of hidden cases, only their verdict is shown.
+func (r *GradeReport) StudentView() *GradeReport {
+	view := *r
+	view.Cases = make([]*CaseResult, 0, len(r.Cases))
+	for _, caseResult := range r.Cases {
+		if !caseResult.Hidden {
+			view.Cases = append(view.Cases, caseResult)
+			continue
+		}
+		view.Cases = append(view.Cases, &CaseResult{
+			TestCaseID: caseResult.TestCaseID,
+			Name:       caseResult.Name,
+			Hidden:     true,
+			Weight:     caseResult.Weight,
+			Passed:     caseResult.Passed,
+			Status:     caseResult.Status,
+			DurationMS: caseResult.DurationMS,
+		})
+	}
+	return &view
+}
+
+func normalizeOutput(output string) []string {
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOutput compares line by line, ignoring trailing whitespace and trailing blank lines.
+func diffOutput(expected, actual string) []string {
+	expectedLines := normalizeOutput(expected)
+	actualLines := normalizeOutput(actual)
+
+	diff := make([]string, 0)
+	lineCount := len(expectedLines)
+	if len(actualLines) > lineCount {
+		lineCount = len(actualLines)
+	}
+
+	for i := 0; i < lineCount; i++ {
+		if len(diff) >= maxDiffLines {
+			diff = append(diff, "...")
+			break
+		}
+
+		switch {
+		case i >= len(actualLines):
+			diff = append(diff, fmt.Sprintf("line %d: expected %q, got nothing", i+1, expectedLines[i]))
+		case i >= len(expectedLines):
+			diff = append(diff, fmt.Sprintf("line %d: unexpected %q", i+1, actualLines[i]))
+		case expectedLines[i] != actualLines[i]:
+			diff = append(diff, fmt.Sprintf("line %d: expected %q, got %q", i+1, expectedLines[i], actualLines[i]))
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,346 @@
+package grading
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"MScProject/online_classroom/access"
+	"MScProject/online_classroom/execution"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+func CreateExerciseHandler(c *gin.Context) {
+	var req CreateExerciseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	if _, err := execution.GlobalLanguageRegistry.Get(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if !access.IsLecturer(req.LectureID, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the lecturer can create exercises",
+		})
+		return
+	}
+
+	testCases := make([]*entities.ExerciseTestCase, 0, len(req.TestCases))
+	for i, tc := range req.TestCases {
+		if tc.ExpectedOutput == "" && tc.TestCode == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Test case " + strconv.Itoa(i+1) + " needs an expected output or test code",
+			})
+			return
+		}
+		if tc.TestCode != "" && !supportsTestCode(req.Language) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Test case " + strconv.Itoa(i+1) + ": test code is not supported for " + req.Language,
+			})
+			return
+		}
+		name := tc.Name
+		if name == "" {
+			name = "Test " + strconv.Itoa(i+1)
+		}
+		testCases = append(testCases, &entities.ExerciseTestCase{
+			Name:           name,
+			Stdin:          tc.Stdin,
+			ExpectedOutput: tc.ExpectedOutput,
+			TestCode:       tc.TestCode,
+			Hidden:         tc.Hidden,
+			Weight:         tc.Weight,
+		})
+	}
+
+	exercise, err := configs.ExerciseApplications.CreateExercise(req.LectureID, req.Title, req.Description, req.Language, zcode, testCases)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    exercise,
+	})
+}
+
+func ListExercisesHandler(c *gin.Context) {
+	lectureID, err := strconv.ParseUint(c.Param("lecture_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid lecture ID",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if _, ok := access.ClassRole(uint(lectureID), zcode); !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You are not a participant of this lecture's class",
+		})
+		return
+	}
+	lecturer := access.IsLecturer(uint(lectureID), zcode)
+
+	exercises, err := configs.ExerciseApplications.FindExercisesByLectureID(uint(lectureID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	data := make([]gin.H, 0, len(exercises))
+	for _, exercise := range exercises {
+		testCases, err := configs.ExerciseApplications.FindTestCasesByExerciseID(exercise.ID)
+		if err != nil {
+			log.Printf("failed to load test cases of exercise %d: %v", exercise.ID, err)
+			continue
+		}
+
+		visibleCases := make([]*entities.ExerciseTestCase, 0, len(testCases))
+		for _, tc := range testCases {
+			if lecturer || !tc.Hidden {
+				visibleCases = append(visibleCases, tc)
+			}
+		}
+
+		data = append(data, gin.H{
+			"exercise":     exercise,
+			"test_cases":   visibleCases,
+			"hidden_count": len(testCases) - len(visibleCases),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func SubmitHandler(c *gin.Context) {
+	var req SubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
+	exercise, err := configs.ExerciseApplications.FindExerciseByID(req.ExerciseID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	testCases, err := configs.ExerciseApplications.FindTestCasesByExerciseID(exercise.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userRole, ok := access.ClassRole(exercise.LectureID, zcode)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You are not a participant of this lecture's class",
+		})
+		return
+	}
+	lecturer := access.IsLecturer(exercise.LectureID, zcode)
+
+	report := GradeSubmission(exercise, testCases, req.Code, strconv.FormatUint(zcode, 10), userRole)
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("failed to marshal grade report: %v", err)
+	}
+	err = configs.ExerciseApplications.SaveSubmission(&entities.ExerciseSubmission{
+		ExerciseID:  exercise.ID,
+		LectureID:   exercise.LectureID,
+		UserZCodeID: zcode,
+		Code:        req.Code,
+		Score:       report.Score,
+		PassedCount: report.PassedCount,
+		TotalCount:  report.TotalCount,
+		DurationMS:  report.DurationMS,
+		Report:      string(reportBytes),
+	})
+	if err != nil {
+		log.Printf("failed to save submission of %d: %v", zcode, err)
+	}
+
+	if !lecturer {
+		report = report.StudentView()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+func ExerciseSummaryHandler(c *gin.Context) {
+	exerciseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid exercise ID",
+		})
+		return
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+
+	exercise, err := configs.ExerciseApplications.FindExerciseByID(uint(exerciseID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !access.IsLecturer(exercise.LectureID, zcode) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the lecturer can view the class summary",
+		})
+		return
+	}
+
+	testCases, err := configs.ExerciseApplications.FindTestCasesByExerciseID(exercise.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	submissions, err := configs.ExerciseApplications.FindSubmissionsByExerciseID(exercise.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	students, caseStats := summarizeSubmissions(testCases, submissions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"exercise":   exercise,
+			"students":   students,
+			"test_cases": caseStats,
+		},
+	})
+}
+
+// summarizeSubmissions builds the pass-rate table from each student's latest submission.
+func summarizeSubmissions(testCases []*entities.ExerciseTestCase, submissions []*entities.ExerciseSubmission) ([]*StudentGradeRow, []*TestCaseStat) {
+	rows := make(map[uint64]*StudentGradeRow)
+	latest := make(map[uint64]*entities.ExerciseSubmission)
+
+	for _, submission := range submissions {
+		row, exists := rows[submission.UserZCodeID]
+		if !exists {
+			row = &StudentGradeRow{UserZCode: strconv.FormatUint(submission.UserZCodeID, 10)}
+			rows[submission.UserZCodeID] = row
+		}
+		row.Attempts++
+		if submission.Score > row.BestScore {
+			row.BestScore = submission.Score
+		}
+		// submissions are ordered by created_at, so the last one wins
+		row.LatestScore = submission.Score
+		row.PassedCount = submission.PassedCount
+		row.TotalCount = submission.TotalCount
+		row.LastSubmittedAt = submission.CreatedAt
+		latest[submission.UserZCodeID] = submission
+	}
+
+	stats := make([]*TestCaseStat, 0, len(testCases))
+	statByID := make(map[uint]*TestCaseStat)
+	for _, tc := range testCases {
+		stat := &TestCaseStat{TestCaseID: tc.ID, Name: tc.Name, Hidden: tc.Hidden}
+		stats = append(stats, stat)
+		statByID[tc.ID] = stat
+	}
+
+	for _, submission := range latest {
+		var report GradeReport
+		if err := json.Unmarshal([]byte(submission.Report), &report); err != nil {
+			continue
+		}
+		for _, caseResult := range report.Cases {
+			stat, exists := statByID[caseResult.TestCaseID]
+			if !exists {
+				continue
+			}
+			stat.TotalStudents++
+			if caseResult.Passed {
+				stat.PassedStudents++
+			}
+		}
+	}
+	for _, stat := range stats {
+		if stat.TotalStudents > 0 {
+			stat.PassRate = float64(stat.PassedStudents) * 100 / float64(stat.TotalStudents)
+		}
+	}
+
+	studentRows := make([]*StudentGradeRow, 0, len(rows))
+	for _, row := range rows {
+		studentRows = append(studentRows, row)
+	}
+	sort.Slice(studentRows, func(i, j int) bool {
+		return studentRows[i].UserZCode < studentRows[j].UserZCode
+	})
+
+	return studentRows, stats
+}
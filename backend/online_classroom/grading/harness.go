@@ -0,0 +1,74 @@
+package grading
+
+import (
+	"MScProject/online_classroom/execution"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/goccy/go-json"
+	"strings"
+)
+
+// testHarness builds the workspace of a test case with test code: the student's
+// code becomes a module and the entrypoint, written by the server, loads it, runs
+// the teacher's test code and prints sentinel last. A program that exits early,
+// e.g. with exit() on its last line, never prints it.
+type testHarness func(code string, testCode string, sentinel string) ([]execution.WorkspaceFile, string)
+
+var testHarnesses = map[string]testHarness{
+	"python":     pythonHarness,
+	"javascript": javascriptHarness,
+}
+
+func pythonHarness(code string, testCode string, sentinel string) ([]execution.WorkspaceFile, string) {
+	quoted, _ := json.Marshal(sentinel)
+	harness := "from solution import *\n\n" + testCode + "\n\nprint(" + string(quoted) + ")\n"
+	return []execution.WorkspaceFile{
+		{Path: "solution.py", Content: code},
+		{Path: "main.py", Content: harness, Trusted: true},
+	}, "main.py"
+}
+
+// javascriptHarness runs both scripts in the global scope, so the test code sees
+// the student's top-level declarations as it would in one file.
+func javascriptHarness(code string, testCode string, sentinel string) ([]execution.WorkspaceFile, string) {
+	quotedTest, _ := json.Marshal(testCode)
+	quotedSentinel, _ := json.Marshal(sentinel)
+	harness := `const fs = require("fs");
+const vm = require("vm");
+globalThis.require = require;
+vm.runInThisContext(fs.readFileSync(__dirname + "/solution.js", "utf8"), { filename: "solution.js" });
+vm.runInThisContext(` + string(quotedTest) + `, { filename: "test.js" });
+console.log(` + string(quotedSentinel) + `);
+`
+	return []execution.WorkspaceFile{
+		{Path: "solution.js", Content: code},
+		{Path: "main.js", Content: harness, Trusted: true},
+	}, "main.js"
+}
+
+func supportsTestCode(language string) bool {
+	_, ok := testHarnesses[language]
+	return ok
+}
+
+// newSentinel is a fresh marker per run, the student's code can not print it
+// ahead of time.
+func newSentinel() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return "grader-ok-" + hex.EncodeToString(buf)
+}
+
+// cutSentinel reports whether stdout ends with the sentinel line and returns
+// the output before it.
+func cutSentinel(stdout string, sentinel string) (string, bool) {
+	trimmed := strings.TrimRight(stdout, " \t\r\n")
+	if !strings.HasSuffix(trimmed, sentinel) {
+		return stdout, false
+	}
+	rest := strings.TrimSuffix(trimmed, sentinel)
+	if rest != "" && !strings.HasSuffix(rest, "\n") {
+		return stdout, false
+	}
+	return rest, true
+}
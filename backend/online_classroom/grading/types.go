@@ -0,0 +1,71 @@
+package grading
+
+import "time"
+
+type TestCaseRequest struct {
+	Name           string `json:"name"`
+	Stdin          string `json:"stdin"`
+	ExpectedOutput string `json:"expected_output"`
+	TestCode       string `json:"test_code"`
+	Hidden         bool   `json:"hidden"`
+	Weight         int    `json:"weight"`
+}
+
+type CreateExerciseRequest struct {
+	LectureID   uint              `json:"lecture_id" binding:"required"`
+	Title       string            `json:"title" binding:"required"`
+	Description string            `json:"description"`
+	Language    string            `json:"language" binding:"required"`
+	TestCases   []TestCaseRequest `json:"test_cases" binding:"required"`
+}
+
+type SubmitRequest struct {
+	ExerciseID uint   `json:"exercise_id" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+}
+
+type CaseResult struct {
+	TestCaseID     uint     `json:"test_case_id"`
+	Name           string   `json:"name"`
+	Hidden         bool     `json:"hidden"`
+	Weight         int      `json:"weight"`
+	Passed         bool     `json:"passed"`
+	Status         string   `json:"status"`
+	Stdin          string   `json:"stdin,omitempty"`
+	ExpectedOutput string   `json:"expected_output,omitempty"`
+	ActualOutput   string   `json:"actual_output,omitempty"`
+	Diff           []string `json:"diff,omitempty"`
+	Error          string   `json:"error,omitempty"`
+	DurationMS     int64    `json:"duration_ms"`
+}
+
+type GradeReport struct {
+	ExerciseID  uint          `json:"exercise_id"`
+	LectureID   uint          `json:"lecture_id"`
+	UserZCode   string        `json:"user_zcode"`
+	Score       float64       `json:"score"` // percentage of the total weight
+	PassedCount int           `json:"passed_count"`
+	TotalCount  int           `json:"total_count"`
+	DurationMS  int64         `json:"duration_ms"`
+	GradedAt    time.Time     `json:"graded_at"`
+	Cases       []*CaseResult `json:"cases"`
+}
+
+type StudentGradeRow struct {
+	UserZCode       string     `json:"user_zcode"`
+	Attempts        int        `json:"attempts"`
+	BestScore       float64    `json:"best_score"`
+	LatestScore     float64    `json:"latest_score"`
+	PassedCount     int        `json:"passed_count"`
+	TotalCount      int        `json:"total_count"`
+	LastSubmittedAt *time.Time `json:"last_submitted_at"`
+}
+
+type TestCaseStat struct {
+	TestCaseID     uint    `json:"test_case_id"`
+	Name           string  `json:"name"`
+	Hidden         bool    `json:"hidden"`
+	PassedStudents int     `json:"passed_students"`
+	TotalStudents  int     `json:"total_students"`
+	PassRate       float64 `json:"pass_rate"`
+}
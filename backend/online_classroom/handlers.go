@@ -1,20 +1,26 @@
 package online_classroom
 
 import (
+	"MScProject/configs"
+	"MScProject/online_classroom/access"
 	"MScProject/online_classroom/classroom"
+	"MScProject/online_classroom/execution"
 	"MScProject/online_classroom/websocket"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+// JoinClassroomRequest names the lecture to join. Who joins and as what comes
+// from the token; the other fields are still sent by older clients and ignored.
 type JoinClassroomRequest struct {
 	LectureID     uint   `json:"lecture_id" binding:"required"`
-	ZCode         string `json:"zcode" binding:"required"`
-	LecturerZcode string `json:"lecturer_zcode" binding:"required"`
+	ZCode         string `json:"zcode"`
+	LecturerZcode string `json:"lecturer_zcode"`
 	Name          string `json:"name"`
-	LectureName   string `json:"lecture_name" binding:"required"`
+	LectureName   string `json:"lecture_name"`
 }
 
 type JoinClassroomResponse struct {
@@ -32,28 +38,42 @@ func JoinClassroomHandler(c *gin.Context) {
 		})
 		return
 	}
-
-	if req.Name == "" {
-		req.Name = req.ZCode
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	lecture, classRole, err := access.LectureAccess(req.LectureID, zcode, time.Now())
+	if err != nil {
+		c.JSON(access.AccessStatus(err), JoinClassroomResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
 	}
-	userrole := "student"
-	if req.LecturerZcode == req.ZCode {
-		userrole = "teacher"
+
+	name := c.GetString("username")
+	if name == "" {
+		name = strconv.FormatUint(zcode, 10)
 	}
-	log.Println(req, userrole)
-	wsURL := "ws://51.107.216.21:8081/ws/classroom/" + strconv.Itoa(int(req.LectureID)) +
-		"?zcode=" + req.ZCode + "&role=" + userrole + "&name=" + req.Name
+	userrole := access.ClassroomRole(classRole)
+	log.Printf("user %d joins lecture %d as %s (%s)", zcode, lecture.ID, userrole, classRole)
+	// the client adds its login token, the server resolves who it is
+	wsURL := "ws://51.107.216.21:8081/ws/classroom/" + strconv.Itoa(int(req.LectureID))
 
 	c.JSON(http.StatusOK, JoinClassroomResponse{
 		Success: true,
 		Data: gin.H{
-			"lecture_id":    req.LectureID,
-			"lecture_name":  req.LectureName,
-			"user_zcode":    req.ZCode,
-			"user_name":     req.Name,
-			"user_role":     userrole,
-			"websocket_url": wsURL,
-			"message":       "Ready to join classroom",
+			"lecture_id":     lecture.ID,
+			"lecture_name":   lecture.LectureName,
+			"lecturer_zcode": strconv.FormatUint(lecture.LecturerZCodeID, 10),
+			"start_time":     lecture.StartTime,
+			"end_time":       lecture.EndTime,
+			"user_zcode":     strconv.FormatUint(zcode, 10),
+			"user_name":      name,
+			"user_role":      userrole,
+			"class_role":     classRole,
+			"websocket_url":  wsURL,
+			"message":        "Ready to join classroom",
 		},
 	})
 }
@@ -68,12 +88,14 @@ func GetClassroomStateHandler(c *gin.Context) {
 		})
 		return
 	}
-
-	zcode := c.Query("zcode")
-	if zcode == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if _, _, err := access.LectureAccess(uint(lectureID), zcode, time.Now()); err != nil {
+		c.JSON(access.AccessStatus(err), gin.H{
 			"success": false,
-			"error":   "Missing zcode parameter",
+			"error":   err.Error(),
 		})
 		return
 	}
@@ -86,6 +108,9 @@ func GetClassroomStateHandler(c *gin.Context) {
 	})
 }
 
+// WebSocketUpgradeHandler authenticates the upgrade with the login token; the
+// role in the classroom comes from the lecture and the class participants, not
+// from the client, and the same schedule as for joining applies.
 func WebSocketUpgradeHandler(c *gin.Context) {
 	lectureIDStr := c.Param("lecture_id")
 	lectureID, err := strconv.ParseUint(lectureIDStr, 10, 32)
@@ -94,18 +119,38 @@ func WebSocketUpgradeHandler(c *gin.Context) {
 		return
 	}
 
-	websocket.GlobalWSManager.HandleWebSocket(c.Writer, c.Request, uint(lectureID))
+	token := websocket.RequestToken(c.Request)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
+		return
+	}
+	userinfo, err := configs.Authtokens.CheckParseToken(token)
+	if err != nil || userinfo == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+	_, classRole, err := access.LectureAccess(uint(lectureID), userinfo.UserZcodeInfo, time.Now())
+	if err != nil {
+		c.JSON(access.AccessStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	role := access.ClassroomRole(classRole)
+
+	zcode := strconv.FormatUint(userinfo.UserZcodeInfo, 10)
+	websocket.GlobalWSManager.HandleWebSocket(c.Writer, c.Request, uint(lectureID), zcode, userinfo.UsernameInfo, role)
 }
 
 func GetStatsHandler(c *gin.Context) {
 	wsStats := websocket.GlobalWSManager.GetStats()
 	classroomStats := classroom.GlobalClassroomManager.GetStats()
+	executionStats := execution.GlobalExecutionManager.GetStats()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
 			"websocket": wsStats,
 			"classroom": classroomStats,
+			"execution": executionStats,
 			"timestamp": gin.H{
 				"server_time": "now",
 			},
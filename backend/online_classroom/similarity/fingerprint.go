@@ -0,0 +1,145 @@
+package similarity
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+const (
+	// KGramTokens is the length of a fingerprinted token sequence, shorter
+	// matches are treated as coincidence.
+	KGramTokens = 8
+	// WinnowWindow is the number of consecutive k-grams one fingerprint is picked
+	// from; every match of KGramTokens+WinnowWindow-1 tokens shares a fingerprint.
+	WinnowWindow = 4
+	// a k-gram repeated more often than this inside one file is boilerplate
+	// (a block of prints, a table), matching it would only slow the tiling down
+	maxOccurrences = 8
+)
+
+// fingerprints maps the selected k-gram hashes of a file to the token positions
+// they start at.
+type fingerprints map[uint64][]int
+
+// winnow hashes every k-gram of tokens and keeps the minimum hash of each window
+// of WinnowWindow k-grams (the rightmost on ties), as in Schleimer et al.,
+// "Winnowing: Local Algorithms for Document Fingerprinting".
+func winnow(tokens []token) fingerprints {
+	prints := make(fingerprints)
+	if len(tokens) < KGramTokens {
+		return prints
+	}
+
+	hashes := make([]uint64, len(tokens)-KGramTokens+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, tok := range tokens[i : i+KGramTokens] {
+			h.Write([]byte(tok.text))
+			h.Write([]byte{0})
+		}
+		hashes[i] = h.Sum64()
+	}
+
+	window := WinnowWindow
+	if window > len(hashes) {
+		window = len(hashes)
+	}
+	lastPicked := -1
+	for start := 0; start+window <= len(hashes); start++ {
+		minimum := start
+		for i := start + 1; i < start+window; i++ {
+			if hashes[i] <= hashes[minimum] {
+				minimum = i
+			}
+		}
+		if minimum != lastPicked {
+			prints[hashes[minimum]] = append(prints[hashes[minimum]], minimum)
+			lastPicked = minimum
+		}
+	}
+	return prints
+}
+
+// tile is a run of equal tokens in two files.
+type tile struct {
+	startA int
+	startB int
+	length int
+}
+
+// matchTiles finds the shared regions of two files: every fingerprint both have
+// seeds a match that is extended in both directions over equal tokens, then the
+// longest matches are kept first and a token belongs to one tile at most
+// (greedy string tiling). ignored holds fingerprints too common to count.
+func matchTiles(tokensA []token, printsA fingerprints, tokensB []token, printsB fingerprints, ignored map[uint64]bool) []tile {
+	candidates := make([]tile, 0)
+	seen := make(map[[2]int]bool)
+	for hash, positionsA := range printsA {
+		positionsB, shared := printsB[hash]
+		if !shared || ignored[hash] || len(positionsA) > maxOccurrences || len(positionsB) > maxOccurrences {
+			continue
+		}
+		for _, a := range positionsA {
+			for _, b := range positionsB {
+				a := a
+				// both seeds of one run extend to the same tile
+				for a > 0 && b > 0 && tokensA[a-1].text == tokensB[b-1].text {
+					a--
+					b--
+				}
+				if seen[[2]int{a, b}] {
+					continue
+				}
+				seen[[2]int{a, b}] = true
+				length := 0
+				for a+length < len(tokensA) && b+length < len(tokensB) && tokensA[a+length].text == tokensB[b+length].text {
+					length++
+				}
+				if length >= KGramTokens {
+					candidates = append(candidates, tile{startA: a, startB: b, length: length})
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].length != candidates[j].length {
+			return candidates[i].length > candidates[j].length
+		}
+		return candidates[i].startA < candidates[j].startA
+	})
+
+	coveredA := make([]bool, len(tokensA))
+	coveredB := make([]bool, len(tokensB))
+	tiles := make([]tile, 0)
+	for _, candidate := range candidates {
+		// a longer tile may have taken part of this one, keep the longest free run
+		best := tile{}
+		run := tile{}
+		for i := 0; i < candidate.length; i++ {
+			a, b := candidate.startA+i, candidate.startB+i
+			if coveredA[a] || coveredB[b] {
+				run = tile{}
+				continue
+			}
+			if run.length == 0 {
+				run = tile{startA: a, startB: b}
+			}
+			run.length++
+			if run.length > best.length {
+				best = run
+			}
+		}
+		if best.length < KGramTokens {
+			continue
+		}
+		for i := 0; i < best.length; i++ {
+			coveredA[best.startA+i] = true
+			coveredB[best.startB+i] = true
+		}
+		tiles = append(tiles, best)
+	}
+	sort.Slice(tiles, func(i, j int) bool {
+		return tiles[i].startA < tiles[j].startA
+	})
+	return tiles
+}
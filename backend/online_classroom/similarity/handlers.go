@@ -0,0 +1,102 @@
+package similarity
+
+import (
+	"MScProject/configs"
+	"MScProject/online_classroom/access"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// LectureReportHandler ranks the student pairs of a lecture by code similarity.
+// Query: source ("submissions", "executions", "documents", all when empty), exercise_id,
+// language, min_similarity (0-1), limit.
+func LectureReportHandler(c *gin.Context) {
+	lectureID, err := strconv.ParseUint(c.Param("lecture_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid lecture_id",
+		})
+		return
+	}
+
+	options := ReportOptions{
+		Source:   c.Query("source"),
+		Language: c.Query("language"),
+	}
+	if options.Source != "" && options.Source != "submissions" && options.Source != "executions" && options.Source != "documents" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "source must be submissions, executions or documents",
+		})
+		return
+	}
+	if value := c.Query("exercise_id"); value != "" {
+		exerciseID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid exercise_id",
+			})
+			return
+		}
+		options.ExerciseID = uint(exerciseID)
+	}
+	if value := c.Query("min_similarity"); value != "" {
+		minSimilarity, err := strconv.ParseFloat(value, 64)
+		if err != nil || minSimilarity < 0 || minSimilarity > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "min_similarity must be between 0 and 1",
+			})
+			return
+		}
+		options.MinSimilarity = minSimilarity
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid limit",
+			})
+			return
+		}
+		options.Limit = limit
+	}
+
+	zcode, ok := access.TokenZCode(c)
+	if !ok {
+		return
+	}
+	if role, ok := access.ClassRole(uint(lectureID), zcode); !ok || role != "teacher" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only teachers can view the similarity report",
+		})
+		return
+	}
+
+	lecture, err := configs.ClassApplications.FindLectureByLectureID(uint(lectureID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	report, err := BuildLectureReport(lecture, options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,281 @@
+package similarity
+
+import (
+	"MScProject/configs"
+	"MScProject/core_app/domain/entities"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMinSimilarity = 0.3
+	DefaultPairLimit     = 100
+	MaxPairLimit         = 1000
+
+	// the newest executions of a lecture that are looked at
+	maxExecutionsScanned = 5000
+	// from this many students on, a fingerprint most of them share is starter code
+	commonCodeMinDocuments = 4
+)
+
+// BuildLectureReport ranks the student pairs of a lecture by how much of their
+// latest code matches. Code of the lecturer and the class manager is never
+// compared, it marks starter code that students are expected to share.
+func BuildLectureReport(lecture *entities.Lecture, options ReportOptions) (*Report, error) {
+	if options.MinSimilarity <= 0 {
+		options.MinSimilarity = DefaultMinSimilarity
+	}
+	if options.Limit <= 0 {
+		options.Limit = DefaultPairLimit
+	}
+	if options.Limit > MaxPairLimit {
+		options.Limit = MaxPairLimit
+	}
+
+	documents, err := collectDocuments(lecture, options)
+	if err != nil {
+		return nil, err
+	}
+	teachers := map[string]bool{strconv.FormatUint(lecture.LecturerZCodeID, 10): true}
+	if class, err := configs.ClassApplications.FindClassByID(lecture.ClassID); err == nil {
+		teachers[strconv.FormatUint(class.ClassManagerZCodeID, 10)] = true
+	}
+
+	groups := make(map[string][]*Document)
+	starterCode := make(map[string][]*Document)
+	for _, document := range documents {
+		document.tokens = tokenize(document.Language, document.Code)
+		document.TokenCount = len(document.tokens)
+		if document.TokenCount < KGramTokens {
+			continue
+		}
+		document.fingerprints = winnow(document.tokens)
+		if teachers[document.UserZCode] {
+			starterCode[document.Group] = append(starterCode[document.Group], document)
+			continue
+		}
+		groups[document.Group] = append(groups[document.Group], document)
+	}
+
+	report := &Report{
+		LectureID:     lecture.ID,
+		GeneratedAt:   time.Now(),
+		KGramTokens:   KGramTokens,
+		WinnowWindow:  WinnowWindow,
+		MinSimilarity: options.MinSimilarity,
+		Documents:     make([]*Document, 0),
+		Pairs:         make([]*Pair, 0),
+	}
+	for group, members := range groups {
+		ignored := sharedFingerprints(members, starterCode[group])
+		for i := 0; i < len(members); i++ {
+			for j := i + 1; j < len(members); j++ {
+				report.PairCount++
+				pair := comparePair(members[i], members[j], ignored)
+				if pair.Similarity >= options.MinSimilarity {
+					report.Pairs = append(report.Pairs, pair)
+				}
+			}
+		}
+	}
+
+	sort.Slice(report.Pairs, func(i, j int) bool {
+		if report.Pairs[i].Similarity != report.Pairs[j].Similarity {
+			return report.Pairs[i].Similarity > report.Pairs[j].Similarity
+		}
+		return report.Pairs[i].MatchedTokens > report.Pairs[j].MatchedTokens
+	})
+	if len(report.Pairs) > options.Limit {
+		report.Pairs = report.Pairs[:options.Limit]
+	}
+
+	// only the code of the reported pairs is sent back
+	byID := make(map[string]*Document)
+	for _, document := range documents {
+		byID[document.ID] = document
+	}
+	included := make(map[string]bool)
+	for _, pair := range report.Pairs {
+		for _, id := range []string{pair.DocumentA, pair.DocumentB} {
+			if !included[id] {
+				included[id] = true
+				report.Documents = append(report.Documents, byID[id])
+			}
+		}
+	}
+	return report, nil
+}
+
+// collectDocuments loads the latest code of every user of the lecture, per
+// exercise from the submissions, per language from the execution history and
+// from the saved editor documents of the lecture.
+func collectDocuments(lecture *entities.Lecture, options ReportOptions) ([]*Document, error) {
+	documents := make([]*Document, 0)
+
+	if options.Source == "" || options.Source == "submissions" {
+		exercises, err := configs.ExerciseApplications.FindExercisesByLectureID(lecture.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, exercise := range exercises {
+			if options.ExerciseID != 0 && exercise.ID != options.ExerciseID {
+				continue
+			}
+			if options.Language != "" && exercise.Language != options.Language {
+				continue
+			}
+			submissions, err := configs.ExerciseApplications.FindSubmissionsByExerciseID(exercise.ID)
+			if err != nil {
+				return nil, err
+			}
+			// submissions come oldest first, the last one of a student wins
+			latest := make(map[uint64]*entities.ExerciseSubmission)
+			order := make([]uint64, 0)
+			for _, submission := range submissions {
+				if _, exists := latest[submission.UserZCodeID]; !exists {
+					order = append(order, submission.UserZCodeID)
+				}
+				latest[submission.UserZCodeID] = submission
+			}
+			for _, zcode := range order {
+				submission := latest[zcode]
+				documents = append(documents, &Document{
+					ID:          fmt.Sprintf("submission:%d", submission.ID),
+					Source:      "submission",
+					Group:       fmt.Sprintf("exercise:%d", exercise.ID),
+					Title:       exercise.Title,
+					UserZCode:   strconv.FormatUint(zcode, 10),
+					Language:    exercise.Language,
+					Code:        submission.Code,
+					SubmittedAt: submission.CreatedAt,
+				})
+			}
+		}
+	}
+
+	if (options.Source == "" || options.Source == "executions") && options.ExerciseID == 0 {
+		executions, err := configs.ExecutionApplications.FindExecutionsByLectureID(lecture.ID, time.Time{}, time.Time{}, maxExecutionsScanned)
+		if err != nil {
+			return nil, err
+		}
+		// executions come newest first, the first one of a student per language wins
+		seen := make(map[string]bool)
+		for _, execution := range executions {
+			if options.Language != "" && execution.Language != options.Language {
+				continue
+			}
+			key := execution.UserZCode + "/" + execution.Language
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			documents = append(documents, &Document{
+				ID:          "execution:" + execution.ExecutionID,
+				Source:      "execution",
+				Group:       "language:" + execution.Language,
+				Title:       execution.Language,
+				UserZCode:   execution.UserZCode,
+				Language:    execution.Language,
+				Code:        execution.Code,
+				SubmittedAt: execution.CreatedAt,
+			})
+		}
+	}
+
+	if (options.Source == "" || options.Source == "documents") && options.ExerciseID == 0 {
+		lectureDocuments, err := configs.LectureDocumentApplications.FindLectureDocumentsByLectureID(lecture.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, lectureDocument := range lectureDocuments {
+			// the teacher's editor is starter code like the lecturer's own runs
+			userZCode := strings.TrimPrefix(lectureDocument.DocumentKey, "student-")
+			if lectureDocument.DocumentKey == "teacher-code" {
+				userZCode = strconv.FormatUint(lecture.LecturerZCodeID, 10)
+			} else if userZCode == lectureDocument.DocumentKey || userZCode == "" {
+				continue
+			}
+			// the editor has no language of its own, the one asked for tokenizes it
+			documents = append(documents, &Document{
+				ID:          fmt.Sprintf("document:%d", lectureDocument.ID),
+				Source:      "document",
+				Group:       "documents",
+				Title:       lectureDocument.DocumentKey,
+				UserZCode:   userZCode,
+				Language:    options.Language,
+				Code:        lectureDocument.Content,
+				SubmittedAt: lectureDocument.UpdatedAt,
+			})
+		}
+	}
+	return documents, nil
+}
+
+// sharedFingerprints are the fingerprints that do not point at copying: the ones
+// in the teachers' code and, in a group big enough to tell, the ones most
+// students have.
+func sharedFingerprints(members []*Document, starterCode []*Document) map[uint64]bool {
+	ignored := make(map[uint64]bool)
+	for _, document := range starterCode {
+		for hash := range document.fingerprints {
+			ignored[hash] = true
+		}
+	}
+	if len(members) < commonCodeMinDocuments {
+		return ignored
+	}
+	counts := make(map[uint64]int)
+	for _, document := range members {
+		for hash := range document.fingerprints {
+			counts[hash]++
+		}
+	}
+	for hash, count := range counts {
+		if count*2 > len(members) {
+			ignored[hash] = true
+		}
+	}
+	return ignored
+}
+
+func comparePair(a *Document, b *Document, ignored map[uint64]bool) *Pair {
+	pair := &Pair{
+		Group:      a.Group,
+		DocumentA:  a.ID,
+		DocumentB:  b.ID,
+		UserZCodeA: a.UserZCode,
+		UserZCodeB: b.UserZCode,
+		Regions:    make([]*Region, 0),
+	}
+	linesA := strings.Split(a.Code, "\n")
+	linesB := strings.Split(b.Code, "\n")
+	for _, t := range matchTiles(a.tokens, a.fingerprints, b.tokens, b.fingerprints, ignored) {
+		region := &Region{
+			StartLineA: a.tokens[t.startA].line,
+			EndLineA:   a.tokens[t.startA+t.length-1].line,
+			StartLineB: b.tokens[t.startB].line,
+			EndLineB:   b.tokens[t.startB+t.length-1].line,
+			Tokens:     t.length,
+		}
+		region.CodeA = strings.Join(linesA[region.StartLineA-1:region.EndLineA], "\n")
+		region.CodeB = strings.Join(linesB[region.StartLineB-1:region.EndLineB], "\n")
+		pair.Regions = append(pair.Regions, region)
+		pair.MatchedTokens += t.length
+	}
+
+	pair.Similarity = ratio(2*pair.MatchedTokens, len(a.tokens)+len(b.tokens))
+	pair.CoverageA = ratio(pair.MatchedTokens, len(a.tokens))
+	pair.CoverageB = ratio(pair.MatchedTokens, len(b.tokens))
+	return pair
+}
+
+func ratio(part int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 1000
+}
@@ -0,0 +1,184 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is one lexical unit of a source file after normalization: identifiers
+// become "V", literals "N" and "S", keywords and operators stay as they are.
+// Comments and whitespace produce no tokens, so renaming variables or
+// reformatting a copy does not change its token stream.
+type token struct {
+	text string
+	line int
+}
+
+var keywords = map[string]map[string]bool{
+	"python": wordSet("False None True and as assert async await break class continue def del elif else except " +
+		"finally for from global if import in is lambda nonlocal not or pass raise return try while with yield"),
+	"javascript": wordSet("async await break case catch class const continue debugger default delete do else export " +
+		"extends false finally for function if import in instanceof let new null of return super switch this throw " +
+		"true try typeof undefined var void while with yield"),
+	"java": wordSet("abstract boolean break byte case catch char class continue default do double else enum extends " +
+		"false final finally float for if implements import instanceof int interface long new null package private " +
+		"protected public return short static super switch this throw throws true try var void while"),
+	"c": wordSet("auto break case char const continue default define do double else enum extern float for goto if " +
+		"include int long register return short signed sizeof static struct switch typedef union unsigned void " +
+		"volatile while"),
+	"go": wordSet("break case chan const continue default defer else fallthrough false for func go goto if import " +
+		"interface map nil package range return select struct switch true type var"),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// tokenize normalizes code of language. It never fails: a string or comment left
+// open runs to the end of the file.
+func tokenize(language string, code string) []token {
+	t := &tokenizer{
+		src:      code,
+		line:     1,
+		keywords: keywords[language],
+		hashLine: language == "python",
+		backtick: language == "javascript" || language == "go",
+	}
+	t.run()
+	return t.tokens
+}
+
+type tokenizer struct {
+	src      string
+	pos      int
+	line     int
+	keywords map[string]bool
+	hashLine bool // "#" starts a comment
+	backtick bool // `...` is a string
+	tokens   []token
+}
+
+func (t *tokenizer) emit(text string, line int) {
+	t.tokens = append(t.tokens, token{text: text, line: line})
+}
+
+func (t *tokenizer) run() {
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case c == '\n':
+			t.line++
+			t.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\\':
+			t.pos++
+		case c == '#' && t.hashLine:
+			t.skipLine()
+		case c == '/' && t.peek(1) == '/' && !t.hashLine:
+			t.skipLine()
+		case c == '/' && t.peek(1) == '*' && !t.hashLine:
+			t.skipBlockComment()
+		case c == '"' || c == '\'' || (c == '`' && t.backtick):
+			t.readString()
+		case c >= '0' && c <= '9', c == '.' && t.peek(1) >= '0' && t.peek(1) <= '9':
+			t.readNumber()
+		case c == '_' || c >= utf8.RuneSelf || unicode.IsLetter(rune(c)):
+			t.readWord()
+		default:
+			t.emit(string(c), t.line)
+			t.pos++
+		}
+	}
+}
+
+func (t *tokenizer) peek(offset int) byte {
+	if t.pos+offset < len(t.src) {
+		return t.src[t.pos+offset]
+	}
+	return 0
+}
+
+func (t *tokenizer) skipLine() {
+	for t.pos < len(t.src) && t.src[t.pos] != '\n' {
+		t.pos++
+	}
+}
+
+func (t *tokenizer) skipBlockComment() {
+	t.pos += 2
+	for t.pos < len(t.src) && !(t.src[t.pos] == '*' && t.peek(1) == '/') {
+		if t.src[t.pos] == '\n' {
+			t.line++
+		}
+		t.pos++
+	}
+	t.pos += 2
+}
+
+func (t *tokenizer) readString() {
+	line := t.line
+	quote := t.src[t.pos : t.pos+1]
+	if t.hashLine && (strings.HasPrefix(t.src[t.pos:], `"""`) || strings.HasPrefix(t.src[t.pos:], `'''`)) {
+		quote = t.src[t.pos : t.pos+3]
+	}
+	t.pos += len(quote)
+	for t.pos < len(t.src) && !strings.HasPrefix(t.src[t.pos:], quote) {
+		switch t.src[t.pos] {
+		case '\\':
+			if quote != "`" {
+				t.pos++
+			}
+		case '\n':
+			if len(quote) == 1 && quote != "`" {
+				// an unterminated single line string ends with the line
+				t.emit("S", line)
+				return
+			}
+		}
+		if t.pos < len(t.src) && t.src[t.pos] == '\n' {
+			t.line++
+		}
+		t.pos++
+	}
+	t.pos += len(quote)
+	t.emit("S", line)
+}
+
+func (t *tokenizer) readNumber() {
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		if c != '.' && c != '_' && !(c >= '0' && c <= '9') && !unicode.IsLetter(rune(c)) {
+			break
+		}
+		t.pos++
+	}
+	t.emit("N", t.line)
+}
+
+func (t *tokenizer) readWord() {
+	start := t.pos
+	for t.pos < len(t.src) {
+		r, size := utf8.DecodeRuneInString(t.src[t.pos:])
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		t.pos += size
+	}
+	if t.pos == start {
+		// a symbol outside ASCII
+		_, size := utf8.DecodeRuneInString(t.src[t.pos:])
+		t.emit(t.src[t.pos:t.pos+size], t.line)
+		t.pos += size
+		return
+	}
+	word := t.src[start:t.pos]
+	if t.keywords[word] {
+		t.emit(word, t.line)
+		return
+	}
+	t.emit("V", t.line)
+}
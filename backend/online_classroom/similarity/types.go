@@ -0,0 +1,67 @@
+package similarity
+
+import "time"
+
+// Document is the latest code of one student for one exercise, for one
+// language when it comes from the execution history, or the student's editor
+// in the lecture.
+type Document struct {
+	ID          string     `json:"id"`     // "submission:<id>", "execution:<execution id>" or "document:<id>"
+	Source      string     `json:"source"` // "submission", "execution" or "document"
+	Group       string     `json:"group"`  // documents are only compared within a group
+	Title       string     `json:"title"`
+	UserZCode   string     `json:"user_zcode"`
+	Language    string     `json:"language"`
+	Code        string     `json:"code"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+	TokenCount  int        `json:"token_count"`
+
+	tokens       []token
+	fingerprints fingerprints
+}
+
+// Region is one matching part of a pair, with the lines of both files so they
+// can be shown side by side.
+type Region struct {
+	StartLineA int    `json:"start_line_a"`
+	EndLineA   int    `json:"end_line_a"`
+	StartLineB int    `json:"start_line_b"`
+	EndLineB   int    `json:"end_line_b"`
+	Tokens     int    `json:"tokens"`
+	CodeA      string `json:"code_a"`
+	CodeB      string `json:"code_b"`
+}
+
+type Pair struct {
+	Group      string `json:"group"`
+	DocumentA  string `json:"document_a"`
+	DocumentB  string `json:"document_b"`
+	UserZCodeA string `json:"user_zcode_a"`
+	UserZCodeB string `json:"user_zcode_b"`
+	// Similarity is the share of both files' tokens inside matching regions,
+	// CoverageA and CoverageB the share of each file on its own
+	Similarity    float64   `json:"similarity"`
+	CoverageA     float64   `json:"coverage_a"`
+	CoverageB     float64   `json:"coverage_b"`
+	MatchedTokens int       `json:"matched_tokens"`
+	Regions       []*Region `json:"regions"`
+}
+
+type Report struct {
+	LectureID     uint        `json:"lecture_id"`
+	GeneratedAt   time.Time   `json:"generated_at"`
+	KGramTokens   int         `json:"kgram_tokens"`
+	WinnowWindow  int         `json:"winnow_window"`
+	MinSimilarity float64     `json:"min_similarity"`
+	PairCount     int         `json:"pair_count"` // pairs compared, including the ones below MinSimilarity
+	Documents     []*Document `json:"documents"`
+	Pairs         []*Pair     `json:"pairs"`
+}
+
+type ReportOptions struct {
+	Source        string // "submissions", "executions", "documents" or "" for all
+	ExerciseID    uint
+	Language      string
+	MinSimilarity float64
+	Limit         int
+}
@@ -24,6 +24,18 @@ type ChatMessage struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// MaxChatMessages is how many chat messages a classroom keeps.
+const MaxChatMessages = 100
+
+func NewChatMessage(senderID, content string) *ChatMessage {
+	return &ChatMessage{
+		ID:        generateMessageID(),
+		SenderID:  senderID,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+}
+
 func NewClassroom(lectureID uint, teacherZCode string) *Classroom {
 	return &Classroom{
 		LectureID:    lectureID,
@@ -70,23 +82,21 @@ func (c *Classroom) GetUser(zcode string) *User {
 }
 
 func (c *Classroom) AddChatMessage(senderID, content string) *ChatMessage {
+	msg := NewChatMessage(senderID, content)
+	c.AppendChatMessage(msg)
+	return msg
+}
+
+// AppendChatMessage keeps the last 100 messages of the classroom.
+func (c *Classroom) AppendChatMessage(msg *ChatMessage) {
 	c.ChatMutex.Lock()
 	defer c.ChatMutex.Unlock()
 
-	msg := &ChatMessage{
-		ID:        generateMessageID(),
-		SenderID:  senderID,
-		Content:   content,
-		CreatedAt: time.Now(),
-	}
-
 	c.ChatMessages = append(c.ChatMessages, msg)
 
-	if len(c.ChatMessages) > 100 {
+	if len(c.ChatMessages) > MaxChatMessages {
 		c.ChatMessages = c.ChatMessages[1:]
 	}
-
-	return msg
 }
 
 func (c *Classroom) GetChatMessages() []*ChatMessage {
@@ -1,6 +1,11 @@
 package types
 
-import "time"
+import (
+	"errors"
+	"github.com/goccy/go-json"
+	"strconv"
+	"time"
+)
 
 type WSMessage struct {
 	Type      string      `json:"type"`
@@ -11,16 +16,91 @@ type WSMessage struct {
 }
 
 type YjsData struct {
-	DocumentKey string `json:"document_key"`
-	Update      []byte `json:"update"`
-	StateVector []byte `json:"state_vector,omitempty"`
-	Requester   string `json:"requester,omitempty"`
+	DocumentKey string   `json:"document_key"`
+	Update      YjsBytes `json:"update"`
+	StateVector YjsBytes `json:"state_vector,omitempty"`
+	Requester   string   `json:"requester,omitempty"`
+}
+
+// YjsBytes is a binary Yjs update or state vector. The editor sends them as
+// arrays of numbers (Array.from of a Uint8Array) and expects them back that way,
+// not base64 encoded like other byte slices.
+type YjsBytes []byte
+
+func (b YjsBytes) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+	out := make([]byte, 0, len(b)*4+2)
+	out = append(out, '[')
+	for i, v := range b {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = strconv.AppendUint(out, uint64(v), 10)
+	}
+	return append(out, ']'), nil
+}
+
+func (b *YjsBytes) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*b = nil
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var raw []byte
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		*b = raw
+		return nil
+	}
+	var numbers []int
+	if err := json.Unmarshal(data, &numbers); err != nil {
+		return err
+	}
+	out := make([]byte, len(numbers))
+	for i, n := range numbers {
+		if n < 0 || n > 255 {
+			return errors.New("yjs bytes out of range")
+		}
+		out[i] = byte(n)
+	}
+	*b = out
+	return nil
 }
 
 type ChatData struct {
 	Message string `json:"message"`
 }
 
+type ExecutionSubscribeData struct {
+	StudentZCode string `json:"student_zcode"` // "*" subscribes to every student of the lecture
+}
+
+type ExecutionCancelData struct {
+	ExecutionID string `json:"execution_id"`
+}
+
+type ArtifactShareData struct {
+	ExecutionID string `json:"execution_id"`
+	Path        string `json:"path"`
+}
+
+type ReplOpenData struct {
+	Language  string `json:"language"`
+	Projected bool   `json:"projected"` // teacher only: every student of the lecture sees the session
+}
+
+type ReplInputData struct {
+	SessionID string `json:"session_id"`
+	Input     string `json:"input"`
+}
+
+type ReplCloseData struct {
+	SessionID string `json:"session_id"`
+}
+
 type User struct {
 	ZCode    string    `json:"zcode"`
 	Name     string    `json:"name"`
@@ -39,4 +119,20 @@ const (
 	MSG_ERROR             = "error"
 	MSG_TEACHER_EXECUTION = "teacher_execution"
 	MSG_STUDENT_EXECUTION = "student_execution"
+
+	MSG_EXECUTION_OUTPUT      = "execution_output"
+	MSG_EXECUTION_FINISHED    = "execution_finished"
+	MSG_EXECUTION_SUBSCRIBE   = "execution_subscribe"
+	MSG_EXECUTION_UNSUBSCRIBE = "execution_unsubscribe"
+	MSG_EXECUTION_CANCEL      = "execution_cancel"
+	MSG_EXECUTION_STATS       = "execution_stats"
+	MSG_EXECUTION_ARTIFACT    = "execution_artifact"
+	MSG_ARTIFACT_SHARE        = "artifact_share"
+
+	MSG_REPL_OPEN   = "repl_open"
+	MSG_REPL_INPUT  = "repl_input"
+	MSG_REPL_CLOSE  = "repl_close"
+	MSG_REPL_OPENED = "repl_opened"
+	MSG_REPL_OUTPUT = "repl_output"
+	MSG_REPL_CLOSED = "repl_closed"
 )
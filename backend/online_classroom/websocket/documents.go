@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"MScProject/online_classroom/bus"
+	"MScProject/online_classroom/documents"
+	"MScProject/online_classroom/types"
+	"github.com/goccy/go-json"
+	"log"
+	"strings"
+	"time"
+)
+
+// canEditDocument mirrors who may send updates of a document: the teacher edits
+// every document, a student only their own.
+func canEditDocument(wsConn *WSConnection, documentKey string) bool {
+	if wsConn.UserRole == "teacher" {
+		return documentKey == "teacher-code" || strings.HasPrefix(documentKey, "student-")
+	}
+	return documentKey == "student-"+wsConn.UserZCode
+}
+
+// canReadDocument adds the teacher's code to what a student may read.
+func canReadDocument(wsConn *WSConnection, documentKey string) bool {
+	return canEditDocument(wsConn, documentKey) || documentKey == "teacher-code"
+}
+
+// storeYjsUpdate applies an update to the server copy of the document. A
+// malformed update is reported to the sender and must not be relayed.
+func (wm *WSManager) storeYjsUpdate(wsConn *WSConnection, yjsData *types.YjsData) bool {
+	if len(yjsData.Update) == 0 {
+		return true
+	}
+	if err := documents.GlobalDocumentStore.Apply(wsConn.LectureID, yjsData.DocumentKey, yjsData.Update); err != nil {
+		log.Printf("rejected yjs update of %s from %s: %v", yjsData.DocumentKey, wsConn.UserZCode, err)
+		wm.sendError(wsConn, err.Error())
+		return false
+	}
+	// the other nodes keep their copies in step
+	wm.publish(&bus.Envelope{LectureID: wsConn.LectureID, Audience: bus.AudienceDocument, Document: yjsData.DocumentKey, Message: yjsData.Update})
+	return true
+}
+
+// answerSyncRequest sends the requester what it misses of the document from the
+// server copy. It reports false when the server has nothing of the document yet.
+func (wm *WSManager) answerSyncRequest(wsConn *WSConnection, yjsData *types.YjsData) bool {
+	update, ok, err := documents.GlobalDocumentStore.Sync(wsConn.LectureID, yjsData.DocumentKey, yjsData.StateVector)
+	if err != nil {
+		wm.sendError(wsConn, err.Error())
+		return true
+	}
+	if !ok {
+		return false
+	}
+
+	response := types.WSMessage{
+		Type:      types.MSG_YJS_SYNC_RESPONSE,
+		Sender:    "system",
+		Timestamp: time.Now().Unix(),
+		Data: types.YjsData{
+			DocumentKey: yjsData.DocumentKey,
+			Update:      update,
+			Requester:   wsConn.UserZCode,
+		},
+	}
+	msgBytes, err := json.Marshal(response)
+	if err != nil {
+		return false
+	}
+	wsConn.SendMessage(msgBytes)
+	log.Printf("sync request of %s answered from the server copy", yjsData.DocumentKey)
+	return true
+}
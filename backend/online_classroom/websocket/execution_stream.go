@@ -0,0 +1,307 @@
+package websocket
+
+import (
+	"MScProject/online_classroom/bus"
+	"MScProject/online_classroom/execution"
+	"MScProject/online_classroom/types"
+	"github.com/goccy/go-json"
+	"log"
+	"strconv"
+	"time"
+)
+
+var _ execution.ExecutionStreamer = (*WSManager)(nil)
+
+func (wm *WSManager) PublishOutput(result *execution.ExecutionResult, stream string, chunk string, seq int) {
+	outputMsg := types.WSMessage{
+		Type:      types.MSG_EXECUTION_OUTPUT,
+		Sender:    "system",
+		Target:    result.UserZCode,
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"execution_id": result.ID,
+			"lecture_id":   result.LectureID,
+			"user_zcode":   result.UserZCode,
+			"stream":       stream,
+			"chunk":        chunk,
+			"seq":          seq,
+		},
+	}
+
+	msgBytes, err := json.Marshal(outputMsg)
+	if err != nil {
+		log.Printf("Failed to marshal execution output: %v", err)
+		return
+	}
+	wm.sendToExecutionAudience(result.LectureID, result.UserZCode, msgBytes)
+}
+
+func (wm *WSManager) PublishFinished(result *execution.ExecutionResult) {
+	finishedMsg := types.WSMessage{
+		Type:      types.MSG_EXECUTION_FINISHED,
+		Sender:    "system",
+		Target:    result.UserZCode,
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"execution_id": result.ID,
+			"lecture_id":   result.LectureID,
+			"user_zcode":   result.UserZCode,
+			"result":       result,
+		},
+	}
+
+	msgBytes, err := json.Marshal(finishedMsg)
+	if err != nil {
+		log.Printf("Failed to marshal execution finished: %v", err)
+		return
+	}
+	wm.sendToExecutionAudience(result.LectureID, result.UserZCode, msgBytes)
+	go wm.publishStudentStats(result.LectureID, result.UserZCode)
+
+	for i := range result.Artifacts {
+		artifact := &result.Artifacts[i]
+		if artifact.IsImage() && artifact.Content != nil && artifact.Size <= execution.MaxPushedImageBytes {
+			if msgBytes, err := artifactMessage(result, artifact, result.UserZCode); err == nil {
+				wm.sendToExecutionAudience(result.LectureID, result.UserZCode, msgBytes)
+			}
+		}
+	}
+}
+
+// PublishCancel reaches the node running the execution through the bus, the
+// result arrives as the usual execution_finished message.
+func (wm *WSManager) PublishCancel(result *execution.ExecutionResult) {
+	wm.publish(&bus.Envelope{LectureID: result.LectureID, Audience: bus.AudienceCancel, Execution: result.ID})
+}
+
+// artifactMessage carries an image inline (base64 in JSON), so plots show up without a download.
+func artifactMessage(result *execution.ExecutionResult, artifact *execution.Artifact, target string) ([]byte, error) {
+	artifactMsg := types.WSMessage{
+		Type:      types.MSG_EXECUTION_ARTIFACT,
+		Sender:    "system",
+		Target:    target,
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"execution_id": result.ID,
+			"lecture_id":   result.LectureID,
+			"user_zcode":   result.UserZCode,
+			"path":         artifact.Path,
+			"mime_type":    artifact.MIMEType,
+			"size":         artifact.Size,
+			"content":      artifact.Content,
+		},
+	}
+	msgBytes, err := json.Marshal(artifactMsg)
+	if err != nil {
+		log.Printf("Failed to marshal execution artifact: %v", err)
+	}
+	return msgBytes, err
+}
+
+// handleArtifactShare lets the teacher show an image from any run of the lecture to the whole class.
+func (wm *WSManager) handleArtifactShare(wsConn *WSConnection, message *types.WSMessage) {
+	if wsConn.UserRole != "teacher" {
+		log.Printf("alert: student %s try to share an artifact", wsConn.UserZCode)
+		return
+	}
+
+	dataBytes, err := json.Marshal(message.Data)
+	if err != nil {
+		return
+	}
+	var shareData types.ArtifactShareData
+	if err := json.Unmarshal(dataBytes, &shareData); err != nil || shareData.ExecutionID == "" || shareData.Path == "" {
+		log.Printf("invalid artifact share message from %s", wsConn.UserZCode)
+		return
+	}
+
+	result, err := execution.GlobalExecutionManager.GetResult(shareData.ExecutionID)
+	if err != nil || result.LectureID != wsConn.LectureID {
+		wm.sendError(wsConn, "execution result not found")
+		return
+	}
+	artifact, err := execution.GlobalExecutionManager.GetArtifact(shareData.ExecutionID, shareData.Path)
+	if err != nil {
+		wm.sendError(wsConn, err.Error())
+		return
+	}
+	if !artifact.IsImage() || artifact.Size > execution.MaxPushedImageBytes {
+		wm.sendError(wsConn, "only images up to 2 MB can be shared")
+		return
+	}
+
+	msgBytes, err := artifactMessage(result, artifact, "")
+	if err != nil {
+		return
+	}
+	wm.BroadcastToAll(wsConn.LectureID, msgBytes)
+	log.Printf("teacher %s shared artifact %s of execution %s", wsConn.UserZCode, artifact.Path, result.ID)
+}
+
+// publishStudentStats pushes the student's updated run statistics to the teacher.
+func (wm *WSManager) publishStudentStats(lectureID uint, userZCode string) {
+	stats, err := execution.LectureExecutionStats(lectureID, userZCode)
+	if err != nil || len(stats) == 0 {
+		return
+	}
+
+	statsMsg := types.WSMessage{
+		Type:      types.MSG_EXECUTION_STATS,
+		Sender:    "system",
+		Timestamp: time.Now().Unix(),
+		Data:      stats[0],
+	}
+	msgBytes, err := json.Marshal(statsMsg)
+	if err != nil {
+		log.Printf("Failed to marshal execution stats: %v", err)
+		return
+	}
+	wm.SendToTeacher(lectureID, msgBytes)
+}
+
+// sendToExecutionAudience delivers to the user who ran the code and to every
+// teacher subscribed to that user's stream. Subscriptions live on the node of
+// the teacher, every node looks up its own.
+func (wm *WSManager) sendToExecutionAudience(lectureID uint, userZCode string, msgBytes []byte) {
+	wm.publish(&bus.Envelope{LectureID: lectureID, Audience: bus.AudienceExecution, UserZCode: userZCode, Message: msgBytes})
+}
+
+func (wm *WSManager) handleExecutionSubscribe(wsConn *WSConnection, message *types.WSMessage, subscribe bool) {
+	if wsConn.UserRole != "teacher" {
+		log.Printf("alert: student %s try to subscribe execution stream", wsConn.UserZCode)
+		return
+	}
+
+	dataBytes, err := json.Marshal(message.Data)
+	if err != nil {
+		return
+	}
+	var subData types.ExecutionSubscribeData
+	if err := json.Unmarshal(dataBytes, &subData); err != nil || subData.StudentZCode == "" {
+		log.Printf("invalid execution subscribe message from %s", wsConn.UserZCode)
+		return
+	}
+
+	if subscribe {
+		wm.subscribe(wsConn.LectureID, subData.StudentZCode, wsConn.UserZCode)
+		log.Printf("teacher %s subscribed to execution stream of %s", wsConn.UserZCode, subData.StudentZCode)
+	} else {
+		wm.unsubscribe(wsConn.LectureID, subData.StudentZCode, wsConn.UserZCode)
+		log.Printf("teacher %s unsubscribed from execution stream of %s", wsConn.UserZCode, subData.StudentZCode)
+	}
+}
+
+// handleExecutionCancel stops a run: students may cancel their own, the lecturer any run in the lecture.
+// The outcome reaches everybody through the usual execution_finished message.
+func (wm *WSManager) handleExecutionCancel(wsConn *WSConnection, message *types.WSMessage) {
+	dataBytes, err := json.Marshal(message.Data)
+	if err != nil {
+		return
+	}
+	var cancelData types.ExecutionCancelData
+	if err := json.Unmarshal(dataBytes, &cancelData); err != nil || cancelData.ExecutionID == "" {
+		log.Printf("invalid execution cancel message from %s", wsConn.UserZCode)
+		return
+	}
+
+	result, err := execution.GlobalExecutionManager.GetResult(cancelData.ExecutionID)
+	if err != nil {
+		wm.sendError(wsConn, err.Error())
+		return
+	}
+	zcode, err := strconv.ParseUint(wsConn.UserZCode, 10, 64)
+	if err != nil || !execution.CanCancelExecution(result, zcode) {
+		log.Printf("alert: %s try to cancel execution %s of %s", wsConn.UserZCode, result.ID, result.UserZCode)
+		wm.sendError(wsConn, "you can not cancel this execution")
+		return
+	}
+
+	go func() {
+		if _, err := execution.GlobalExecutionManager.CancelExecution(cancelData.ExecutionID); err != nil {
+			wm.sendError(wsConn, err.Error())
+			return
+		}
+		log.Printf("%s cancelled execution %s", wsConn.UserZCode, cancelData.ExecutionID)
+	}()
+}
+
+func (wm *WSManager) sendError(wsConn *WSConnection, errMsg string) {
+	errorMsg := types.WSMessage{
+		Type:      types.MSG_ERROR,
+		Sender:    "system",
+		Target:    wsConn.UserZCode,
+		Timestamp: time.Now().Unix(),
+		Data: map[string]interface{}{
+			"error": errMsg,
+		},
+	}
+	msgBytes, err := json.Marshal(errorMsg)
+	if err != nil {
+		return
+	}
+	wsConn.SendMessage(msgBytes)
+}
+
+func (wm *WSManager) subscribe(lectureID uint, studentZCode, teacherZCode string) {
+	wm.subMutex.Lock()
+	defer wm.subMutex.Unlock()
+
+	if wm.subscriptions[lectureID] == nil {
+		wm.subscriptions[lectureID] = make(map[string]map[string]bool)
+	}
+	if wm.subscriptions[lectureID][studentZCode] == nil {
+		wm.subscriptions[lectureID][studentZCode] = make(map[string]bool)
+	}
+	wm.subscriptions[lectureID][studentZCode][teacherZCode] = true
+}
+
+func (wm *WSManager) unsubscribe(lectureID uint, studentZCode, teacherZCode string) {
+	wm.subMutex.Lock()
+	defer wm.subMutex.Unlock()
+
+	if lectureSubs, exists := wm.subscriptions[lectureID]; exists {
+		delete(lectureSubs[studentZCode], teacherZCode)
+		if len(lectureSubs[studentZCode]) == 0 {
+			delete(lectureSubs, studentZCode)
+		}
+		if len(lectureSubs) == 0 {
+			delete(wm.subscriptions, lectureID)
+		}
+	}
+}
+
+func (wm *WSManager) unsubscribeAll(lectureID uint, teacherZCode string) {
+	wm.subMutex.Lock()
+	defer wm.subMutex.Unlock()
+
+	if lectureSubs, exists := wm.subscriptions[lectureID]; exists {
+		for studentZCode, teachers := range lectureSubs {
+			delete(teachers, teacherZCode)
+			if len(teachers) == 0 {
+				delete(lectureSubs, studentZCode)
+			}
+		}
+		if len(lectureSubs) == 0 {
+			delete(wm.subscriptions, lectureID)
+		}
+	}
+}
+
+func (wm *WSManager) getSubscribers(lectureID uint, studentZCode string) []string {
+	wm.subMutex.RLock()
+	defer wm.subMutex.RUnlock()
+
+	seen := make(map[string]bool)
+	subscribers := make([]string, 0)
+	if lectureSubs, exists := wm.subscriptions[lectureID]; exists {
+		for _, key := range []string{studentZCode, "*"} {
+			for teacherZCode := range lectureSubs[key] {
+				if !seen[teacherZCode] {
+					seen[teacherZCode] = true
+					subscribers = append(subscribers, teacherZCode)
+				}
+			}
+		}
+	}
+	return subscribers
+}
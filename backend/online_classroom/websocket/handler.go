@@ -2,6 +2,8 @@ package websocket
 
 import (
 	"MScProject/online_classroom/classroom"
+	"MScProject/online_classroom/documents"
+	"MScProject/online_classroom/execution"
 	"MScProject/online_classroom/types"
 	"github.com/goccy/go-json"
 	"github.com/gorilla/websocket"
@@ -11,19 +13,20 @@ import (
 
 func (wm *WSManager) handleConnection(wsConn *WSConnection) {
 	defer func() {
-		classroomData := classroom.GlobalClassroomManager.GetClassroom(wsConn.LectureID)
 		var userName string
-		if classroomData != nil {
-			if user := classroomData.GetUser(wsConn.UserZCode); user != nil {
-				userName = user.Name
-			}
+		if user := classroom.GlobalClassroomManager.GetUser(wsConn.LectureID, wsConn.UserZCode); user != nil {
+			userName = user.Name
 		}
 		if userName == "" {
 			userName = wsConn.UserZCode
 		}
 		wsConn.Close()
+		execution.GlobalReplManager.CloseUserSessions(wsConn.LectureID, wsConn.UserZCode)
 		wm.removeConnection(wsConn.LectureID, wsConn.UserZCode)
 		classroom.GlobalClassroomManager.RemoveUser(wsConn.LectureID, wsConn.UserZCode)
+		if len(wm.getClassroomConnections(wsConn.LectureID)) == 0 {
+			documents.GlobalDocumentStore.CloseLecture(wsConn.LectureID)
+		}
 		wm.broadcastUserLeave(wsConn.LectureID, wsConn.UserZCode, userName)
 		log.Printf("WebSocket connection closed: user=%s", wsConn.UserZCode)
 	}()
@@ -153,6 +156,20 @@ func (wm *WSManager) handleMessage(wsConn *WSConnection, message *types.WSMessag
 		wm.handleTeacherExecution(wsConn, message, msgBytes)
 	case types.MSG_STUDENT_EXECUTION:
 		wm.handleStudentExecution(wsConn, message, msgBytes)
+	case types.MSG_EXECUTION_SUBSCRIBE:
+		wm.handleExecutionSubscribe(wsConn, message, true)
+	case types.MSG_EXECUTION_UNSUBSCRIBE:
+		wm.handleExecutionSubscribe(wsConn, message, false)
+	case types.MSG_EXECUTION_CANCEL:
+		wm.handleExecutionCancel(wsConn, message)
+	case types.MSG_ARTIFACT_SHARE:
+		wm.handleArtifactShare(wsConn, message)
+	case types.MSG_REPL_OPEN:
+		wm.handleReplOpen(wsConn, message)
+	case types.MSG_REPL_INPUT:
+		wm.handleReplInput(wsConn, message)
+	case types.MSG_REPL_CLOSE:
+		wm.handleReplClose(wsConn, message)
 	default:
 		log.Printf("Unknown messgae type: %s", message.Type)
 	}
@@ -174,6 +191,10 @@ func (wm *WSManager) handleYjsUpdate(wsConn *WSConnection, message *types.WSMess
 
 	log.Printf("Yjs update: document_key=%s, sender_role=%s", yjsData.DocumentKey, wsConn.UserRole)
 
+	if canEditDocument(wsConn, yjsData.DocumentKey) && !wm.storeYjsUpdate(wsConn, &yjsData) {
+		return
+	}
+
 	if yjsData.DocumentKey == "teacher-code" {
 		if wsConn.UserRole == "teacher" {
 			wm.BroadcastToStudents(wsConn.LectureID, msgBytes)
@@ -209,6 +230,14 @@ func (wm *WSManager) handleYjsSyncRequest(wsConn *WSConnection, message *types.W
 
 	log.Printf("sync request: document_key=%s, requester=%s", yjsData.DocumentKey, wsConn.UserZCode)
 
+	if !canReadDocument(wsConn, yjsData.DocumentKey) {
+		log.Printf("alert: user %s try to read %s", wsConn.UserZCode, yjsData.DocumentKey)
+		return
+	}
+	if wm.answerSyncRequest(wsConn, &yjsData) {
+		return
+	}
+
 	yjsData.Requester = wsConn.UserZCode
 	message.Data = yjsData
 
@@ -242,6 +271,9 @@ func (wm *WSManager) handleYjsSyncResponse(wsConn *WSConnection, message *types.
 	log.Printf("sync response: document_key=%s, requester=%s", yjsData.DocumentKey, yjsData.Requester)
 
 	if yjsData.Requester != "" {
+		if canEditDocument(wsConn, yjsData.DocumentKey) && !wm.storeYjsUpdate(wsConn, &yjsData) {
+			return
+		}
 		wm.SendToUser(wsConn.LectureID, yjsData.Requester, msgBytes)
 		log.Printf("sync response: %s", yjsData.Requester)
 	}
@@ -1,41 +1,85 @@
 package websocket
 
 import (
+	"MScProject/online_classroom/bus"
 	"MScProject/online_classroom/classroom"
+	"MScProject/online_classroom/documents"
+	"MScProject/online_classroom/execution"
 	"github.com/goccy/go-json"
 	"github.com/gorilla/websocket"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
 
+// TokenSubprotocol is offered together with the token by browsers, which cannot
+// set headers on a WebSocket: new WebSocket(url, ["bearer", token]). The
+// upgrader confirms it, a browser drops a connection that does not.
+const TokenSubprotocol = "bearer"
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true
 	},
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    []string{TokenSubprotocol},
+}
+
+// RequestToken reads the login token of an upgrade request from the "token"
+// query parameter, the subprotocol after TokenSubprotocol or, for clients that
+// can set headers, the Authorization header.
+func RequestToken(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	protocols := websocket.Subprotocols(r)
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == TokenSubprotocol {
+			return protocols[i+1]
+		}
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
 }
 
 type WSManager struct {
 	connections map[uint]map[string]*WSConnection
 	mutex       sync.RWMutex
-}
 
-var GlobalWSManager = &WSManager{
-	connections: make(map[uint]map[string]*WSConnection),
+	// lecture -> student zcode (or "*") -> subscribed teacher zcodes
+	subscriptions map[uint]map[string]map[string]bool
+	subMutex      sync.RWMutex
+
+	// every send to other connections goes through the bus, which reaches the
+	// connections of this node and, with Redis, those of the other nodes
+	bus bus.Bus
 }
 
-func (wm *WSManager) HandleWebSocket(w http.ResponseWriter, r *http.Request, lectureID uint) {
-	zcode := r.URL.Query().Get("zcode")
-	role := r.URL.Query().Get("role")
-	name := r.URL.Query().Get("name")
+var GlobalWSManager = newWSManager()
 
-	if zcode == "" || role == "" {
-		http.Error(w, "Missing zcode or role parameter", http.StatusBadRequest)
-		return
+func newWSManager() *WSManager {
+	wm := &WSManager{
+		connections:   make(map[uint]map[string]*WSConnection),
+		subscriptions: make(map[uint]map[string]map[string]bool),
+	}
+	wm.SetBus(bus.NewLocalBus())
+	return wm
+}
+
+// SetBus replaces the bus, before the server accepts connections.
+func (wm *WSManager) SetBus(b bus.Bus) {
+	if wm.bus != nil {
+		wm.bus.Close()
 	}
+	b.Subscribe(wm.deliver)
+	wm.bus = b
+}
+
+// HandleWebSocket upgrades an authenticated request. The caller has checked the
+// token and resolved the user's role in the lecture.
+func (wm *WSManager) HandleWebSocket(w http.ResponseWriter, r *http.Request, lectureID uint, zcode, name, role string) {
 	if name == "" {
 		name = zcode
 	}
@@ -83,6 +127,8 @@ func (wm *WSManager) addConnection(lectureID uint, userZCode string, conn *WSCon
 }
 
 func (wm *WSManager) removeConnection(lectureID uint, userZCode string) {
+	wm.unsubscribeAll(lectureID, userZCode)
+
 	wm.mutex.Lock()
 	defer wm.mutex.Unlock()
 
@@ -111,15 +157,7 @@ func (wm *WSManager) getClassroomConnections(lectureID uint) map[string]*WSConne
 }
 
 func (wm *WSManager) SendToUser(lectureID uint, userZCode string, message []byte) error {
-	wm.mutex.RLock()
-	defer wm.mutex.RUnlock()
-
-	if lectureConns, exists := wm.connections[lectureID]; exists {
-		if conn, userExists := lectureConns[userZCode]; userExists && conn.IsActive {
-			return conn.SendMessage(message)
-		}
-	}
-	return nil
+	return wm.publish(&bus.Envelope{LectureID: lectureID, Audience: bus.AudienceUser, UserZCode: userZCode, Message: message})
 }
 
 func (wm *WSManager) GetStats() map[string]interface{} {
@@ -139,23 +177,12 @@ func (wm *WSManager) GetStats() map[string]interface{} {
 		"total_connections": totalConnections,
 		"lecture_stats":     lectureStats,
 		"active_lectures":   len(wm.connections),
+		"node":              bus.NodeID,
 	}
 }
 
 func (wm *WSManager) BroadcastToOthers(lectureID uint, senderZCode string, message []byte) {
-	wm.mutex.RLock()
-	defer wm.mutex.RUnlock()
-
-	if lectureConns, exists := wm.connections[lectureID]; exists {
-		count := 0
-		for userZCode, conn := range lectureConns {
-			if userZCode != senderZCode && conn.IsActive {
-				conn.SendMessage(message)
-				count++
-			}
-		}
-		log.Printf("Message broadcast to %d others in lecture %d", count, lectureID)
-	}
+	wm.publish(&bus.Envelope{LectureID: lectureID, Audience: bus.AudienceAll, Exclude: senderZCode, Message: message})
 }
 
 func (wm *WSManager) BroadcastToAll(lectureID uint, message []byte) {
@@ -163,34 +190,91 @@ func (wm *WSManager) BroadcastToAll(lectureID uint, message []byte) {
 }
 
 func (wm *WSManager) BroadcastToStudents(lectureID uint, message []byte) {
+	wm.publish(&bus.Envelope{LectureID: lectureID, Audience: bus.AudienceStudents, Message: message})
+}
+
+// SendToTeacher reaches every teacher of the lecture: the lecturer and the TAs,
+// on whichever node they are connected.
+func (wm *WSManager) SendToTeacher(lectureID uint, message []byte) error {
+	return wm.publish(&bus.Envelope{LectureID: lectureID, Audience: bus.AudienceTeachers, Message: message})
+}
+
+// publish puts an envelope on the bus. When the bus fails the connections of
+// this node still get it.
+func (wm *WSManager) publish(envelope *bus.Envelope) error {
+	if err := wm.bus.Publish(envelope); err != nil {
+		log.Printf("classroom bus: publish failed, delivering on this node only: %v", err)
+		envelope.Node = bus.NodeID
+		wm.deliver(envelope)
+		return err
+	}
+	return nil
+}
+
+// deliver hands an envelope from the bus to the connections of this node.
+func (wm *WSManager) deliver(envelope *bus.Envelope) {
+	switch envelope.Audience {
+	case bus.AudienceDocument:
+		// this node applied the update when it arrived
+		if envelope.Node != bus.NodeID {
+			if err := documents.GlobalDocumentStore.Apply(envelope.LectureID, envelope.Document, envelope.Message); err != nil {
+				log.Printf("failed to apply update of %s from node %s: %v", envelope.Document, envelope.Node, err)
+			}
+		}
+		return
+	case bus.AudienceCancel:
+		// only the node running the execution has it, CancelLocal waits for it to stop
+		go execution.GlobalExecutionManager.CancelLocal(envelope.Execution)
+		return
+	case bus.AudienceUser:
+		wm.sendLocal(envelope.LectureID, envelope.UserZCode, envelope.Message)
+		return
+	case bus.AudienceExecution:
+		wm.sendLocal(envelope.LectureID, envelope.UserZCode, envelope.Message)
+		for _, subscriber := range wm.getSubscribers(envelope.LectureID, envelope.UserZCode) {
+			if subscriber != envelope.UserZCode {
+				wm.sendLocal(envelope.LectureID, subscriber, envelope.Message)
+			}
+		}
+		return
+	}
+
 	wm.mutex.RLock()
 	defer wm.mutex.RUnlock()
 
-	if lectureConns, exists := wm.connections[lectureID]; exists {
-		count := 0
-		for _, conn := range lectureConns {
-			if conn.UserRole == "student" && conn.IsActive {
-				conn.SendMessage(message)
-				count++
+	for userZCode, conn := range wm.connections[envelope.LectureID] {
+		if !conn.IsActive {
+			continue
+		}
+		switch envelope.Audience {
+		case bus.AudienceAll:
+			if userZCode == envelope.Exclude {
+				continue
+			}
+		case bus.AudienceStudents:
+			if conn.UserRole != "student" {
+				continue
 			}
+		case bus.AudienceTeachers:
+			if conn.UserRole != "teacher" {
+				continue
+			}
+		default:
+			continue
 		}
-		log.Printf("Message broadcast to %d students in lecture %d", count, lectureID)
+		conn.SendMessage(envelope.Message)
 	}
 }
 
-func (wm *WSManager) SendToTeacher(lectureID uint, message []byte) error {
+func (wm *WSManager) sendLocal(lectureID uint, userZCode string, message []byte) {
 	wm.mutex.RLock()
 	defer wm.mutex.RUnlock()
 
 	if lectureConns, exists := wm.connections[lectureID]; exists {
-		for _, conn := range lectureConns {
-			if conn.UserRole == "teacher" && conn.IsActive {
-				return conn.SendMessage(message)
-			}
+		if conn, userExists := lectureConns[userZCode]; userExists && conn.IsActive {
+			conn.SendMessage(message)
 		}
 	}
-	log.Printf("Teacher not found in lecture %d", lectureID)
-	return nil
 }
 
 func (wm *WSManager) broadcastUserJoin(lectureID uint, userZCode, userName, userRole string) {
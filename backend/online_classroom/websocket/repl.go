@@ -0,0 +1,130 @@
+package websocket
+
+import (
+	"MScProject/online_classroom/execution"
+	"MScProject/online_classroom/types"
+	"github.com/goccy/go-json"
+	"log"
+	"time"
+)
+
+var _ execution.ReplStreamer = (*WSManager)(nil)
+
+// handleReplOpen starts an interactive session for the sender. The teacher may
+// project it to the whole lecture.
+func (wm *WSManager) handleReplOpen(wsConn *WSConnection, message *types.WSMessage) {
+	dataBytes, err := json.Marshal(message.Data)
+	if err != nil {
+		return
+	}
+	var openData types.ReplOpenData
+	if err := json.Unmarshal(dataBytes, &openData); err != nil {
+		log.Printf("invalid repl open message from %s", wsConn.UserZCode)
+		return
+	}
+	if openData.Language == "" {
+		openData.Language = "python"
+	}
+	if openData.Projected && wsConn.UserRole != "teacher" {
+		log.Printf("alert: student %s try to project a repl session", wsConn.UserZCode)
+		wm.sendError(wsConn, "only the teacher can project a session")
+		return
+	}
+
+	// starting a container takes a while, the read pump must not wait for it
+	go func() {
+		session, err := execution.GlobalReplManager.Open(wsConn.LectureID, wsConn.UserZCode, wsConn.UserRole, openData.Language, openData.Projected)
+		if err != nil {
+			wm.sendError(wsConn, err.Error())
+			return
+		}
+		wm.publishRepl(session, types.MSG_REPL_OPENED, map[string]interface{}{
+			"session": session,
+		})
+	}()
+}
+
+func (wm *WSManager) handleReplInput(wsConn *WSConnection, message *types.WSMessage) {
+	dataBytes, err := json.Marshal(message.Data)
+	if err != nil {
+		return
+	}
+	var inputData types.ReplInputData
+	if err := json.Unmarshal(dataBytes, &inputData); err != nil || inputData.SessionID == "" {
+		log.Printf("invalid repl input message from %s", wsConn.UserZCode)
+		return
+	}
+
+	if err := execution.GlobalReplManager.Input(inputData.SessionID, wsConn.UserZCode, inputData.Input); err != nil {
+		wm.sendError(wsConn, err.Error())
+	}
+}
+
+// handleReplClose ends a session: the owner's own, or any session of the lecture for the teacher.
+func (wm *WSManager) handleReplClose(wsConn *WSConnection, message *types.WSMessage) {
+	dataBytes, err := json.Marshal(message.Data)
+	if err != nil {
+		return
+	}
+	var closeData types.ReplCloseData
+	if err := json.Unmarshal(dataBytes, &closeData); err != nil || closeData.SessionID == "" {
+		log.Printf("invalid repl close message from %s", wsConn.UserZCode)
+		return
+	}
+
+	isLectureTeacher := false
+	if wsConn.UserRole == "teacher" {
+		for _, session := range execution.GlobalReplManager.List(wsConn.LectureID) {
+			if session.ID == closeData.SessionID {
+				isLectureTeacher = true
+				break
+			}
+		}
+	}
+	if err := execution.GlobalReplManager.Close(closeData.SessionID, wsConn.UserZCode, isLectureTeacher); err != nil {
+		wm.sendError(wsConn, err.Error())
+	}
+}
+
+func (wm *WSManager) PublishReplOutput(session *execution.ReplSessionInfo, stream string, chunk string, seq int) {
+	wm.publishRepl(session, types.MSG_REPL_OUTPUT, map[string]interface{}{
+		"stream": stream,
+		"chunk":  chunk,
+		"seq":    seq,
+	})
+}
+
+func (wm *WSManager) PublishReplClosed(session *execution.ReplSessionInfo, reason string) {
+	wm.publishRepl(session, types.MSG_REPL_CLOSED, map[string]interface{}{
+		"reason": reason,
+	})
+}
+
+// publishRepl sends a session message to everybody who watches the session: the
+// whole lecture for a projected session, otherwise the owner and the teachers
+// subscribed to the owner's executions.
+func (wm *WSManager) publishRepl(session *execution.ReplSessionInfo, msgType string, data map[string]interface{}) {
+	data["session_id"] = session.ID
+	data["lecture_id"] = session.LectureID
+	data["user_zcode"] = session.UserZCode
+	data["projected"] = session.Projected
+
+	replMsg := types.WSMessage{
+		Type:      msgType,
+		Sender:    "system",
+		Target:    session.UserZCode,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	}
+	msgBytes, err := json.Marshal(replMsg)
+	if err != nil {
+		log.Printf("Failed to marshal repl message: %v", err)
+		return
+	}
+
+	if session.Projected {
+		wm.BroadcastToAll(session.LectureID, msgBytes)
+		return
+	}
+	wm.sendToExecutionAudience(session.LectureID, session.UserZCode, msgBytes)
+}
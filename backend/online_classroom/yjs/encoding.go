@@ -0,0 +1,204 @@
+package yjs
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var errUnexpectedEnd = errors.New("yjs: unexpected end of update")
+
+// decoder reads the lib0 encoding used by Yjs update format v1.
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *decoder) remaining() int {
+	return len(d.buf) - d.pos
+}
+
+func (d *decoder) readByte() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, errUnexpectedEnd
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *decoder) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(d.remaining()) {
+		return nil, errUnexpectedEnd
+	}
+	b := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// readVarUint reads an unsigned LEB128 number, lib0 keeps them below 2^53.
+func (d *decoder) readVarUint() (uint64, error) {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return value, nil
+		}
+	}
+	return 0, errors.New("yjs: varuint overflows 64 bits")
+}
+
+// readCount reads a number of entries that each take at least one more byte,
+// so a forged count cannot make us allocate more than the update's size.
+func (d *decoder) readCount() (int, error) {
+	n, err := d.readVarUint()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(d.remaining()) {
+		return 0, errUnexpectedEnd
+	}
+	return int(n), nil
+}
+
+func (d *decoder) readVarString() (string, error) {
+	n, err := d.readVarUint()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.readBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) skipVarInt() error {
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return err
+		}
+		if b < 0x80 {
+			return nil
+		}
+	}
+}
+
+// skipAny steps over one value of lib0's writeAny encoding.
+func (d *decoder) skipAny(depth int) error {
+	if depth > 64 {
+		return errors.New("yjs: value nested too deep")
+	}
+	tag, err := d.readByte()
+	if err != nil {
+		return err
+	}
+	switch tag {
+	case 127, 126, 121, 120: // undefined, null, false, true
+		return nil
+	case 125: // integer
+		return d.skipVarInt()
+	case 124: // float32
+		_, err = d.readBytes(4)
+		return err
+	case 123, 122: // float64, bigint64
+		_, err = d.readBytes(8)
+		return err
+	case 119: // string
+		_, err = d.readVarString()
+		return err
+	case 118: // object
+		n, err := d.readCount()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if _, err := d.readVarString(); err != nil {
+				return err
+			}
+			if err := d.skipAny(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 117: // array
+		n, err := d.readCount()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := d.skipAny(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 116: // Uint8Array
+		n, err := d.readVarUint()
+		if err != nil {
+			return err
+		}
+		_, err = d.readBytes(n)
+		return err
+	default:
+		return errors.New("yjs: unknown value type")
+	}
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) writeByte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) writeVarUint(n uint64) {
+	for n >= 0x80 {
+		e.buf = append(e.buf, byte(n)|0x80)
+		n >>= 7
+	}
+	e.buf = append(e.buf, byte(n))
+}
+
+func (e *encoder) writeVarString(s string) {
+	e.writeVarUint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// utf16Len is the length of s in JavaScript string units, which is what Yjs
+// counts clocks of text in.
+func utf16Len(s string) uint64 {
+	var n uint64
+	for _, r := range s {
+		if r >= 0x10000 && r <= utf8.MaxRune {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// splitUTF16 cuts s after offset JavaScript string units. A surrogate pair cut
+// in half becomes U+FFFD on both sides, as Yjs does.
+func splitUTF16(s string, offset uint64) (string, string) {
+	units := utf16.Encode([]rune(s))
+	if offset == 0 {
+		return "", s
+	}
+	if offset >= uint64(len(units)) {
+		return s, ""
+	}
+	left := append([]uint16{}, units[:offset]...)
+	right := append([]uint16{}, units[offset:]...)
+	if last := left[len(left)-1]; last >= 0xd800 && last <= 0xdbff {
+		left[len(left)-1] = 0xfffd
+		right[0] = 0xfffd
+	}
+	return string(utf16.Decode(left)), string(utf16.Decode(right))
+}
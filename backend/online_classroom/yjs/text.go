@@ -0,0 +1,271 @@
+package yjs
+
+import (
+	"sort"
+	"strings"
+)
+
+// node is an integrated struct. Items of the text are linked in document order.
+type node struct {
+	id          ID
+	length      uint64
+	gc          bool
+	inText      bool // the item belongs to the text being read
+	deleted     bool
+	origin      *ID
+	rightOrigin *ID
+	content     content
+	left        *node
+	right       *node
+}
+
+// textReader replays an update the way Y.Doc integrates it, keeping only what
+// is needed to order the items of one root Y.Text.
+type textReader struct {
+	name       string
+	pending    map[uint64][]*Struct
+	nodes      map[uint64][]*node
+	state      map[uint64]uint64
+	inProgress map[uint64]bool
+	start      *node
+}
+
+// Text returns the content of the root Y.Text called name in update, which must
+// hold a whole document (a state, not an incremental update).
+func Text(update []byte, name string) (string, error) {
+	decoded, err := DecodeUpdate(update)
+	if err != nil {
+		return "", err
+	}
+	r := &textReader{
+		name:       name,
+		pending:    decoded.Structs,
+		nodes:      make(map[uint64][]*node),
+		state:      make(map[uint64]uint64),
+		inProgress: make(map[uint64]bool),
+	}
+	for _, client := range sortedClients(decoded.Structs) {
+		r.integrateClient(client, ^uint64(0))
+	}
+	for _, client := range sortedClients(decoded.DeleteSet) {
+		for _, deleted := range decoded.DeleteSet[client] {
+			r.delete(client, deleted)
+		}
+	}
+
+	var text strings.Builder
+	for n := r.start; n != nil; n = n.right {
+		if !n.deleted && n.content.ref == contentString {
+			text.WriteString(n.content.str)
+		}
+	}
+	return text.String(), nil
+}
+
+// integrateClient integrates the structs of client until its clock passes
+// clock. Structs that wait for something the update does not hold stop the
+// client, as they stay pending in Yjs.
+func (r *textReader) integrateClient(client uint64, clock uint64) {
+	if r.inProgress[client] {
+		return
+	}
+	r.inProgress[client] = true
+	defer delete(r.inProgress, client)
+
+	for len(r.pending[client]) > 0 && r.state[client] <= clock {
+		s := r.pending[client][0]
+		if s.ID.Clock != r.state[client] || !r.integrate(s) {
+			r.pending[client] = nil
+			return
+		}
+		r.pending[client] = r.pending[client][1:]
+		r.state[client] = s.end()
+	}
+}
+
+// available integrates what id depends on and reports whether it exists.
+func (r *textReader) available(id *ID) bool {
+	if r.state[id.Client] <= id.Clock {
+		r.integrateClient(id.Client, id.Clock)
+	}
+	return r.state[id.Client] > id.Clock
+}
+
+func (r *textReader) integrate(s *Struct) bool {
+	n := &node{id: s.ID, length: s.Length, gc: !s.isItem(), origin: s.origin, rightOrigin: s.rightOrigin, content: s.content}
+	if s.ref == refSkip {
+		return false
+	}
+	if n.gc {
+		r.add(n)
+		return true
+	}
+	if (s.origin != nil && !r.available(s.origin)) || (s.rightOrigin != nil && !r.available(s.rightOrigin)) {
+		return false
+	}
+
+	var left, right *node
+	if s.origin != nil {
+		left = r.cleanEnd(*s.origin)
+	}
+	if s.rightOrigin != nil {
+		right = r.cleanStart(*s.rightOrigin)
+	}
+	switch {
+	case s.hasParent:
+		n.inText = s.parentID == nil && s.parentKey == r.name && s.parentSub == nil
+	case left != nil:
+		n.inText = left.inText
+		n.gc = left.gc
+	case right != nil:
+		n.inText = right.inText
+		n.gc = right.gc
+	}
+	// an item next to garbage collected content is garbage collected too
+	if n.gc {
+		n.inText = false
+	}
+	r.add(n)
+	if n.inText {
+		r.link(n, left, right)
+	}
+	return true
+}
+
+// link places n between its origins following YATA, as Item.integrate does.
+func (r *textReader) link(n *node, left *node, right *node) {
+	if (left == nil && (right == nil || right.left != nil)) || (left != nil && left.right != right) {
+		o := r.start
+		if left != nil {
+			o = left.right
+		}
+		conflicting := make(map[*node]bool)
+		beforeOrigin := make(map[*node]bool)
+		for o != nil && o != right {
+			beforeOrigin[o] = true
+			conflicting[o] = true
+			if sameID(n.origin, o.origin) {
+				if o.id.Client < n.id.Client {
+					left = o
+					conflicting = make(map[*node]bool)
+				} else if sameID(n.rightOrigin, o.rightOrigin) {
+					break
+				}
+			} else if originNode := r.find(o.origin); originNode != nil && beforeOrigin[originNode] {
+				if !conflicting[originNode] {
+					left = o
+					conflicting = make(map[*node]bool)
+				}
+			} else {
+				break
+			}
+			o = o.right
+		}
+	}
+
+	n.left = left
+	if left != nil {
+		right = left.right
+		left.right = n
+	} else {
+		right = r.start
+		r.start = n
+	}
+	n.right = right
+	if right != nil {
+		right.left = n
+	}
+}
+
+func (r *textReader) delete(client uint64, deleted Range) {
+	end := deleted.Clock + deleted.Length
+	if state := r.state[client]; end > state {
+		end = state
+	}
+	for clock := deleted.Clock; clock < end; {
+		n := r.cleanStart(ID{Client: client, Clock: clock})
+		if n.id.Clock+n.length > end {
+			r.split(n, end-n.id.Clock)
+		}
+		n.deleted = true
+		clock = n.id.Clock + n.length
+	}
+}
+
+func sameID(a *ID, b *ID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (r *textReader) add(n *node) {
+	r.nodes[n.id.Client] = append(r.nodes[n.id.Client], n)
+}
+
+// index finds the node of client holding clock, which must be integrated.
+func (r *textReader) index(id ID) int {
+	nodes := r.nodes[id.Client]
+	return sort.Search(len(nodes), func(i int) bool {
+		return nodes[i].id.Clock+nodes[i].length > id.Clock
+	})
+}
+
+func (r *textReader) find(id *ID) *node {
+	if id == nil || r.state[id.Client] <= id.Clock {
+		return nil
+	}
+	return r.nodes[id.Client][r.index(*id)]
+}
+
+// cleanStart returns the node starting at id, splitting the one holding it.
+func (r *textReader) cleanStart(id ID) *node {
+	n := r.nodes[id.Client][r.index(id)]
+	if n.id.Clock < id.Clock {
+		return r.split(n, id.Clock-n.id.Clock)
+	}
+	return n
+}
+
+// cleanEnd returns the node ending at id, splitting the one holding it.
+func (r *textReader) cleanEnd(id ID) *node {
+	n := r.nodes[id.Client][r.index(id)]
+	if n.id.Clock+n.length-1 > id.Clock {
+		r.split(n, id.Clock-n.id.Clock+1)
+	}
+	return n
+}
+
+// split cuts n after diff clock units. n keeps the left part and the right part
+// is returned, linked after it.
+func (r *textReader) split(n *node, diff uint64) *node {
+	right := &node{
+		id:      ID{Client: n.id.Client, Clock: n.id.Clock + diff},
+		length:  n.length - diff,
+		gc:      n.gc,
+		inText:  n.inText,
+		deleted: n.deleted,
+	}
+	n.length = diff
+	if !n.gc {
+		right.origin = &ID{Client: n.id.Client, Clock: n.id.Clock + diff - 1}
+		right.rightOrigin = n.rightOrigin
+		right.content = n.content.splice(diff)
+	}
+	if n.inText {
+		right.left = n
+		right.right = n.right
+		if n.right != nil {
+			n.right.left = right
+		}
+		n.right = right
+	}
+
+	nodes := r.nodes[n.id.Client]
+	i := r.index(n.id) + 1
+	nodes = append(nodes, nil)
+	copy(nodes[i+1:], nodes[i:])
+	nodes[i] = right
+	r.nodes[n.id.Client] = nodes
+	return right
+}
@@ -0,0 +1,530 @@
+// Package yjs reads and writes Yjs document updates (format v1) so the server
+// can keep collaborative documents without running Yjs itself. It merges and
+// diffs updates the way Y.mergeUpdates and Y.diffUpdate do and extracts the
+// text of a Y.Text.
+package yjs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// content references of Item structs
+const (
+	contentDeleted = 1
+	contentJSON    = 2
+	contentBinary  = 3
+	contentString  = 4
+	contentEmbed   = 5
+	contentFormat  = 6
+	contentType    = 7
+	contentAny     = 8
+	contentDoc     = 9
+
+	refGC   = 0
+	refSkip = 10
+
+	typeXMLElement = 3
+	typeXMLHook    = 5
+)
+
+// ID is a struct position: the client that created it and its clock.
+type ID struct {
+	Client uint64
+	Clock  uint64
+}
+
+type content struct {
+	ref    byte
+	length uint64
+	str    string   // contentString
+	parts  [][]byte // encoded elements of contentJSON and contentAny
+	raw    []byte   // encoded body of the contents that are always one long
+}
+
+// splice cuts the content after offset clock units and returns the right part.
+func (c *content) splice(offset uint64) content {
+	right := content{ref: c.ref, length: c.length - offset}
+	switch c.ref {
+	case contentString:
+		c.str, right.str = splitUTF16(c.str, offset)
+	case contentJSON, contentAny:
+		right.parts = c.parts[offset:]
+		c.parts = c.parts[:offset:offset]
+	}
+	c.length = offset
+	return right
+}
+
+// Struct is an Item, a GC (garbage collected range) or a Skip (range the update
+// does not contain).
+type Struct struct {
+	ID     ID
+	Length uint64
+	ref    byte
+
+	origin      *ID
+	rightOrigin *ID
+	parentKey   string // name of the root type
+	parentID    *ID    // item of a nested type
+	hasParent   bool   // parent info is written, it is when neither origin is
+	parentSub   *string
+	content     content
+}
+
+func (s *Struct) isItem() bool {
+	return s.ref != refGC && s.ref != refSkip
+}
+
+func (s *Struct) end() uint64 {
+	return s.ID.Clock + s.Length
+}
+
+// slice cuts the struct after diff clock units and returns the right part, the
+// way Yjs slices structs when updates overlap.
+func (s *Struct) slice(diff uint64) *Struct {
+	right := &Struct{
+		ID:     ID{Client: s.ID.Client, Clock: s.ID.Clock + diff},
+		Length: s.Length - diff,
+		ref:    s.ref,
+	}
+	s.Length = diff
+	if !s.isItem() {
+		return right
+	}
+	right.origin = &ID{Client: s.ID.Client, Clock: s.ID.Clock + diff - 1}
+	right.rightOrigin = s.rightOrigin
+	right.parentSub = s.parentSub
+	right.content = s.content.splice(diff)
+	return right
+}
+
+// Range is a run of deleted clocks of one client.
+type Range struct {
+	Clock  uint64
+	Length uint64
+}
+
+// Update is a decoded update: the structs of every client in clock order and
+// the deleted ranges.
+type Update struct {
+	Structs   map[uint64][]*Struct
+	DeleteSet map[uint64][]Range
+}
+
+// DecodeUpdate parses an update in format v1.
+func DecodeUpdate(data []byte) (*Update, error) {
+	d := &decoder{buf: data}
+	update := &Update{
+		Structs:   make(map[uint64][]*Struct),
+		DeleteSet: make(map[uint64][]Range),
+	}
+
+	clients, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < clients; i++ {
+		count, err := d.readCount()
+		if err != nil {
+			return nil, err
+		}
+		client, err := d.readVarUint()
+		if err != nil {
+			return nil, err
+		}
+		clock, err := d.readVarUint()
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < count; j++ {
+			s, err := readStruct(d, ID{Client: client, Clock: clock})
+			if err != nil {
+				return nil, err
+			}
+			if s.Length == 0 {
+				return nil, errors.New("yjs: empty struct")
+			}
+			update.Structs[client] = append(update.Structs[client], s)
+			clock += s.Length
+		}
+	}
+
+	clients, err = d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < clients; i++ {
+		client, err := d.readVarUint()
+		if err != nil {
+			return nil, err
+		}
+		count, err := d.readCount()
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < count; j++ {
+			clock, err := d.readVarUint()
+			if err != nil {
+				return nil, err
+			}
+			length, err := d.readVarUint()
+			if err != nil {
+				return nil, err
+			}
+			update.DeleteSet[client] = append(update.DeleteSet[client], Range{Clock: clock, Length: length})
+		}
+	}
+	return update, nil
+}
+
+func readID(d *decoder) (*ID, error) {
+	client, err := d.readVarUint()
+	if err != nil {
+		return nil, err
+	}
+	clock, err := d.readVarUint()
+	if err != nil {
+		return nil, err
+	}
+	return &ID{Client: client, Clock: clock}, nil
+}
+
+func readStruct(d *decoder, id ID) (*Struct, error) {
+	info, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	s := &Struct{ID: id, ref: info & 0x1f}
+	if s.ref == refGC || s.ref == refSkip {
+		s.Length, err = d.readVarUint()
+		return s, err
+	}
+
+	if info&0x80 != 0 {
+		if s.origin, err = readID(d); err != nil {
+			return nil, err
+		}
+	}
+	if info&0x40 != 0 {
+		if s.rightOrigin, err = readID(d); err != nil {
+			return nil, err
+		}
+	}
+	if info&0xc0 == 0 {
+		s.hasParent = true
+		isKey, err := d.readVarUint()
+		if err != nil {
+			return nil, err
+		}
+		if isKey == 1 {
+			if s.parentKey, err = d.readVarString(); err != nil {
+				return nil, err
+			}
+		} else if s.parentID, err = readID(d); err != nil {
+			return nil, err
+		}
+		if info&0x20 != 0 {
+			parentSub, err := d.readVarString()
+			if err != nil {
+				return nil, err
+			}
+			s.parentSub = &parentSub
+		}
+	}
+
+	if s.content, err = readContent(d, s.ref); err != nil {
+		return nil, err
+	}
+	s.Length = s.content.length
+	return s, nil
+}
+
+func readContent(d *decoder, ref byte) (content, error) {
+	c := content{ref: ref, length: 1}
+	start := d.pos
+	var err error
+	switch ref {
+	case contentDeleted:
+		c.length, err = d.readVarUint()
+		return c, err
+	case contentJSON, contentAny:
+		count, err := d.readCount()
+		if err != nil {
+			return c, err
+		}
+		c.length = uint64(count)
+		c.parts = make([][]byte, 0, count)
+		for i := 0; i < count; i++ {
+			partStart := d.pos
+			if ref == contentJSON {
+				_, err = d.readVarString()
+			} else {
+				err = d.skipAny(0)
+			}
+			if err != nil {
+				return c, err
+			}
+			c.parts = append(c.parts, d.buf[partStart:d.pos])
+		}
+		return c, nil
+	case contentString:
+		if c.str, err = d.readVarString(); err != nil {
+			return c, err
+		}
+		c.length = utf16Len(c.str)
+		return c, nil
+	case contentBinary, contentEmbed:
+		_, err = d.readVarString()
+	case contentFormat:
+		if _, err = d.readVarString(); err == nil {
+			_, err = d.readVarString()
+		}
+	case contentType:
+		var typeRef uint64
+		if typeRef, err = d.readVarUint(); err == nil && (typeRef == typeXMLElement || typeRef == typeXMLHook) {
+			_, err = d.readVarString()
+		}
+	case contentDoc:
+		if _, err = d.readVarString(); err == nil {
+			err = d.skipAny(0)
+		}
+	default:
+		return c, fmt.Errorf("yjs: unknown content type %d", ref)
+	}
+	c.raw = d.buf[start:d.pos]
+	return c, err
+}
+
+// Encode writes the update in format v1. The structs of a client must be
+// contiguous, which MergeUpdates and DiffUpdate guarantee.
+func (u *Update) Encode() []byte {
+	e := &encoder{}
+	clients := sortedClients(u.Structs)
+	e.writeVarUint(uint64(len(clients)))
+	for _, client := range clients {
+		structs := u.Structs[client]
+		e.writeVarUint(uint64(len(structs)))
+		e.writeVarUint(client)
+		e.writeVarUint(structs[0].ID.Clock)
+		for _, s := range structs {
+			writeStruct(e, s)
+		}
+	}
+
+	clients = sortedClients(u.DeleteSet)
+	e.writeVarUint(uint64(len(clients)))
+	for _, client := range clients {
+		e.writeVarUint(client)
+		e.writeVarUint(uint64(len(u.DeleteSet[client])))
+		for _, r := range u.DeleteSet[client] {
+			e.writeVarUint(r.Clock)
+			e.writeVarUint(r.Length)
+		}
+	}
+	return e.buf
+}
+
+// sortedClients lists the clients with entries, highest first like Yjs.
+func sortedClients[T any](byClient map[uint64][]T) []uint64 {
+	clients := make([]uint64, 0, len(byClient))
+	for client, entries := range byClient {
+		if len(entries) > 0 {
+			clients = append(clients, client)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i] > clients[j]
+	})
+	return clients
+}
+
+func writeID(e *encoder, id *ID) {
+	e.writeVarUint(id.Client)
+	e.writeVarUint(id.Clock)
+}
+
+func writeStruct(e *encoder, s *Struct) {
+	if !s.isItem() {
+		e.writeByte(s.ref)
+		e.writeVarUint(s.Length)
+		return
+	}
+
+	info := s.ref
+	if s.origin != nil {
+		info |= 0x80
+	}
+	if s.rightOrigin != nil {
+		info |= 0x40
+	}
+	writeParent := s.origin == nil && s.rightOrigin == nil
+	if writeParent && s.parentSub != nil {
+		info |= 0x20
+	}
+	e.writeByte(info)
+	if s.origin != nil {
+		writeID(e, s.origin)
+	}
+	if s.rightOrigin != nil {
+		writeID(e, s.rightOrigin)
+	}
+	if writeParent {
+		if s.parentID != nil {
+			e.writeVarUint(0)
+			writeID(e, s.parentID)
+		} else {
+			e.writeVarUint(1)
+			e.writeVarString(s.parentKey)
+		}
+		if s.parentSub != nil {
+			e.writeVarString(*s.parentSub)
+		}
+	}
+
+	switch s.content.ref {
+	case contentDeleted:
+		e.writeVarUint(s.content.length)
+	case contentJSON, contentAny:
+		e.writeVarUint(uint64(len(s.content.parts)))
+		for _, part := range s.content.parts {
+			e.buf = append(e.buf, part...)
+		}
+	case contentString:
+		e.writeVarString(s.content.str)
+	default:
+		e.buf = append(e.buf, s.content.raw...)
+	}
+}
+
+// MergeUpdates combines updates into one that contains everything any of them
+// does. Ranges several updates contain are written once, gaps become Skips.
+func MergeUpdates(updates ...[]byte) ([]byte, error) {
+	merged := &Update{
+		Structs:   make(map[uint64][]*Struct),
+		DeleteSet: make(map[uint64][]Range),
+	}
+	all := make(map[uint64][]*Struct)
+	for _, data := range updates {
+		update, err := DecodeUpdate(data)
+		if err != nil {
+			return nil, err
+		}
+		for client, structs := range update.Structs {
+			for _, s := range structs {
+				// a Skip carries nothing, gaps are filled again below
+				if s.ref != refSkip {
+					all[client] = append(all[client], s)
+				}
+			}
+		}
+		for client, ranges := range update.DeleteSet {
+			merged.DeleteSet[client] = append(merged.DeleteSet[client], ranges...)
+		}
+	}
+
+	for client, structs := range all {
+		sort.SliceStable(structs, func(i, j int) bool {
+			if structs[i].ID.Clock != structs[j].ID.Clock {
+				return structs[i].ID.Clock < structs[j].ID.Clock
+			}
+			if structs[i].Length != structs[j].Length {
+				return structs[i].Length > structs[j].Length
+			}
+			// an item keeps its content, a GC of the same range does not
+			return structs[i].isItem() && !structs[j].isItem()
+		})
+		written := make([]*Struct, 0, len(structs))
+		var next uint64
+		for _, s := range structs {
+			if len(written) > 0 {
+				if s.end() <= next {
+					continue
+				}
+				if s.ID.Clock < next {
+					s = s.slice(next - s.ID.Clock)
+				} else if s.ID.Clock > next {
+					written = append(written, &Struct{ID: ID{Client: client, Clock: next}, Length: s.ID.Clock - next, ref: refSkip})
+				}
+			}
+			written = append(written, s)
+			next = s.end()
+		}
+		merged.Structs[client] = written
+	}
+	for client, ranges := range merged.DeleteSet {
+		merged.DeleteSet[client] = mergeRanges(ranges)
+	}
+	return merged.Encode(), nil
+}
+
+func mergeRanges(ranges []Range) []Range {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Clock < ranges[j].Clock
+	})
+	merged := make([]Range, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Length == 0 {
+			continue
+		}
+		if n := len(merged); n > 0 && r.Clock <= merged[n-1].Clock+merged[n-1].Length {
+			if end := r.Clock + r.Length; end > merged[n-1].Clock+merged[n-1].Length {
+				merged[n-1].Length = end - merged[n-1].Clock
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// DecodeStateVector parses a state vector: the next clock of every client.
+func DecodeStateVector(data []byte) (map[uint64]uint64, error) {
+	d := &decoder{buf: data}
+	count, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	stateVector := make(map[uint64]uint64, count)
+	for i := 0; i < count; i++ {
+		client, err := d.readVarUint()
+		if err != nil {
+			return nil, err
+		}
+		clock, err := d.readVarUint()
+		if err != nil {
+			return nil, err
+		}
+		stateVector[client] = clock
+	}
+	return stateVector, nil
+}
+
+// DiffUpdate drops from update what a peer with stateVector already has. The
+// delete set is kept whole, as Yjs does.
+func DiffUpdate(data []byte, stateVector map[uint64]uint64) ([]byte, error) {
+	update, err := DecodeUpdate(data)
+	if err != nil {
+		return nil, err
+	}
+	for client, structs := range update.Structs {
+		known := stateVector[client]
+		kept := make([]*Struct, 0, len(structs))
+		for _, s := range structs {
+			if s.end() <= known {
+				continue
+			}
+			if s.ID.Clock < known {
+				s = s.slice(known - s.ID.Clock)
+			}
+			kept = append(kept, s)
+		}
+		// a client's structs may not start with a Skip
+		for len(kept) > 0 && kept[0].ref == refSkip {
+			kept = kept[1:]
+		}
+		update.Structs[client] = kept
+	}
+	return update.Encode(), nil
+}
@@ -124,3 +124,209 @@ CREATE TABLE IF NOT Exists role_auth_point (
     CONSTRAINT uniq_role_auth UNIQUE (role_id, auth_point_id)
 );
 
+CREATE TABLE IF NOT Exists exercises (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    lecture_id BIGINT UNSIGNED NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    language VARCHAR(50) NOT NULL,
+    created_by_zcode_id BIGINT UNSIGNED,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    CONSTRAINT fk_exercise_lecture FOREIGN KEY (lecture_id)
+        REFERENCES lectures(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT Exists exercise_test_cases (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    exercise_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(255),
+    stdin TEXT,
+    expected_output TEXT,
+    test_code TEXT,
+    hidden BOOLEAN DEFAULT FALSE,
+    weight INT NOT NULL DEFAULT 1,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    CONSTRAINT fk_testcase_exercise FOREIGN KEY (exercise_id)
+        REFERENCES exercises(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT Exists exercise_submissions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    exercise_id BIGINT UNSIGNED NOT NULL,
+    lecture_id BIGINT UNSIGNED NOT NULL,
+    user_zcode_id BIGINT UNSIGNED NOT NULL,
+    code TEXT,
+    score DOUBLE NOT NULL DEFAULT 0,
+    passed_count INT NOT NULL DEFAULT 0,
+    total_count INT NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    report MEDIUMTEXT,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    INDEX idx_submission_exercise_user (exercise_id, user_zcode_id),
+
+    CONSTRAINT fk_submission_exercise FOREIGN KEY (exercise_id)
+        REFERENCES exercises(id)
+        ON DELETE CASCADE
+);
+
+
+CREATE TABLE IF NOT Exists executions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    execution_id VARCHAR(64) NOT NULL,
+    lecture_id BIGINT UNSIGNED NOT NULL,
+    user_zcode VARCHAR(64) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    code TEXT,
+    status VARCHAR(32) NOT NULL,
+    output MEDIUMTEXT,
+    error TEXT,
+    exit_code INT NOT NULL DEFAULT 0,
+    stdin_exhausted BOOLEAN DEFAULT FALSE,
+    compile_status VARCHAR(32),
+    compile_output TEXT,
+    compile_duration_ms BIGINT NOT NULL DEFAULT 0,
+    diagnostics TEXT,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    peak_memory_bytes BIGINT NOT NULL DEFAULT 0,
+    cpu_time_ms BIGINT NOT NULL DEFAULT 0,
+    oom_killed BOOLEAN DEFAULT FALSE,
+    timed_out BOOLEAN DEFAULT FALSE,
+    output_truncated BOOLEAN DEFAULT FALSE,
+    output_bytes BIGINT NOT NULL DEFAULT 0,
+    queued_at DATETIME,
+    executed_at DATETIME,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    UNIQUE INDEX idx_execution_id (execution_id),
+    INDEX idx_execution_lecture_time (lecture_id, created_at),
+    INDEX idx_execution_user_time (user_zcode, created_at),
+    INDEX idx_execution_lecture_user_time (lecture_id, user_zcode, created_at),
+    INDEX idx_execution_created (created_at)
+);
+
+CREATE TABLE IF NOT Exists execution_artifacts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    execution_id VARCHAR(64) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(100),
+    size BIGINT NOT NULL DEFAULT 0,
+    content MEDIUMBLOB,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    UNIQUE INDEX idx_artifact_execution_path (execution_id, path),
+    INDEX idx_artifact_created (created_at)
+);
+
+CREATE TABLE IF NOT Exists execution_profiles (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    class_id BIGINT UNSIGNED NOT NULL,
+    lecture_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    language VARCHAR(50) NOT NULL DEFAULT '',
+    user_role VARCHAR(50) NOT NULL DEFAULT '',
+    timeout_seconds INT NOT NULL DEFAULT 0,
+    memory_limit_mb INT NOT NULL DEFAULT 0,
+    cpu_limit DOUBLE NOT NULL DEFAULT 0,
+    output_limit_kb INT NOT NULL DEFAULT 0,
+    allowed_packages TEXT,
+    created_by_zcode_id BIGINT UNSIGNED,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    INDEX idx_execution_profile_class (class_id, lecture_id),
+    CONSTRAINT fk_execution_profile_class FOREIGN KEY (class_id)
+        REFERENCES classes(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT Exists class_policies (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    class_id BIGINT UNSIGNED NOT NULL,
+    policy_name VARCHAR(50) NOT NULL,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    UNIQUE INDEX idx_class_policy_class (class_id),
+    CONSTRAINT fk_class_policy_class FOREIGN KEY (class_id)
+        REFERENCES classes(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT Exists runtime_images (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    language VARCHAR(50) NOT NULL,
+    image VARCHAR(255) NOT NULL,
+    description TEXT,
+    packages TEXT,
+    created_by_zcode_id BIGINT UNSIGNED,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    INDEX idx_runtime_image_language (language)
+);
+
+CREATE TABLE IF NOT Exists class_runtimes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    class_id BIGINT UNSIGNED NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    runtime_image_id BIGINT UNSIGNED NOT NULL,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    UNIQUE INDEX idx_class_runtime_language (class_id, language),
+    CONSTRAINT fk_class_runtime_class FOREIGN KEY (class_id)
+        REFERENCES classes(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_class_runtime_image FOREIGN KEY (runtime_image_id)
+        REFERENCES runtime_images(id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT Exists lecture_documents (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    lecture_id BIGINT UNSIGNED NOT NULL,
+    document_key VARCHAR(100) NOT NULL,
+    state MEDIUMBLOB,
+    content MEDIUMTEXT,
+    update_count BIGINT UNSIGNED DEFAULT 0,
+    updated_at DATETIME,
+    finalized_at DATETIME,
+
+    created_at DATETIME,
+    is_delete BOOLEAN DEFAULT FALSE,
+    deleted_at DATETIME,
+
+    UNIQUE INDEX idx_lecture_document_key (lecture_id, document_key),
+    CONSTRAINT fk_lecture_document_lecture FOREIGN KEY (lecture_id)
+        REFERENCES lectures(id)
+        ON DELETE CASCADE
+);
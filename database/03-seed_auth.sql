@@ -150,3 +150,15 @@ SELECT 'POST', '/class/participant/byCID', 'POST_CLASS_PARTICIPANT_BYCID', NOW()
 INSERT INTO auth_point (request_method, request_path, permission_code, created_at, is_delete)
 SELECT 'GET', '/class/participant/my_classes', 'GET_CLASS_PARTICIPANT_MYCLASSES', NOW(), 0
     WHERE NOT EXISTS (SELECT 1 FROM auth_point WHERE permission_code = 'GET_CLASS_PARTICIPANT_MYCLASSES');
+
+INSERT INTO auth_point (request_method, request_path, permission_code, created_at, is_delete)
+SELECT 'POST', '/api/execution/runtime/create', 'POST_EXECUTION_RUNTIME_CREATE', NOW(), 0
+    WHERE NOT EXISTS (SELECT 1 FROM auth_point WHERE permission_code = 'POST_EXECUTION_RUNTIME_CREATE');
+
+INSERT INTO auth_point (request_method, request_path, permission_code, created_at, is_delete)
+SELECT 'POST', '/api/execution/runtime/update', 'POST_EXECUTION_RUNTIME_UPDATE', NOW(), 0
+    WHERE NOT EXISTS (SELECT 1 FROM auth_point WHERE permission_code = 'POST_EXECUTION_RUNTIME_UPDATE');
+
+INSERT INTO auth_point (request_method, request_path, permission_code, created_at, is_delete)
+SELECT 'POST', '/api/execution/runtime/delete', 'POST_EXECUTION_RUNTIME_DELETE', NOW(), 0
+    WHERE NOT EXISTS (SELECT 1 FROM auth_point WHERE permission_code = 'POST_EXECUTION_RUNTIME_DELETE');
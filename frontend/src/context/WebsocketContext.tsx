@@ -60,12 +60,14 @@ export const WebSocketProvider: React.FC<WebSocketProviderProps> = ({
     const eventListeners = useRef<Map<string, Set<(data: any) => void>>>(new Map())
 
     useEffect(() => {
-        const wsUrl = `ws://51.107.216.21:8081/ws/classroom/${lectureId}?zcode=${userZCode}&role=${userRole}&name=${encodeURIComponent(userName)}`
+        const wsUrl = `ws://51.107.216.21:8081/ws/classroom/${lectureId}`
+        // the server reads who we are from the login token, sent as a subprotocol
+        const token = localStorage.getItem('token') ?? ''
 
         console.log(`[WebSocket] Connecting to: ${wsUrl}`)
         setConnectionStatus('connecting')
 
-        const ws = new WebSocket(wsUrl)
+        const ws = new WebSocket(wsUrl, ['bearer', token])
 
         ws.onopen = () => {
             console.log('[WebSocket] Connected successfully')
@@ -16,9 +16,169 @@ export interface ExecutionResult {
     exit_code: number;
     duration_ms: number;
     executed_at: string;
-    status: 'running' | 'completed' | 'failed' | 'timeout';
+    status: 'queued' | 'running' | 'completed' | 'failed' | 'timeout' | 'compile_error' | 'cancelled' | 'memory_exceeded' | 'output_limit';
+    queue_position?: number;
+    stdin_exhausted?: boolean;
+    violations?: PolicyViolation[];
+    artifacts?: ExecutionArtifact[];
+    peak_memory_bytes: number;
+    cpu_time_ms: number;
+    oom_killed: boolean;
+    timed_out: boolean;
+    output_truncated?: boolean;
+    output_bytes: number;
 }
 
+export interface ExecutionArtifact {
+    path: string;
+    mime_type: string;
+    size: number;
+}
+
+export interface PolicyViolation {
+    file?: string;
+    line?: number;
+    rule: 'size' | 'syntax' | 'import' | 'builtin';
+    message: string;
+}
+
+export interface StudentExecutionStats {
+    user_zcode: string;
+    run_count: number;
+    success_count: number;
+    error_count: number;
+    error_ratio: number;
+    last_run_at: string | null;
+    last_error: string;
+    last_error_at: string | null;
+}
+
+export interface WorkspaceFile {
+    path: string;
+    content: string;
+}
+
+export interface SupportedLanguage {
+    name: string;
+    display_name: string;
+    version: string;
+    image: string;
+    source_file: string;
+}
+
+export interface ExecutionConfig {
+    timeout_seconds: number;
+    compile_timeout_seconds: number;
+    memory_limit_mb: number;
+    cpu_limit: number;
+    network_access: boolean;
+    artifact_limit_mb: number;
+    output_limit_kb: number;
+}
+
+export interface ExecutionProfile {
+    id: number;
+    name: string;
+    class_id: number;
+    lecture_id: number;
+    language: string;
+    user_role: string;
+    timeout_seconds: number;
+    memory_limit_mb: number;
+    cpu_limit: number;
+    output_limit_kb: number;
+    allowed_packages: string;
+    created_by_zcode_id: number;
+}
+
+export interface ExecutionProfileRequest {
+    name: string;
+    class_id: number;
+    lecture_id?: number;
+    language?: string;
+    user_role?: string;
+    timeout_seconds?: number;
+    memory_limit_mb?: number;
+    cpu_limit?: number;
+    output_limit_kb?: number;
+    allowed_packages?: string[];
+}
+
+export interface ResolvedExecutionProfile {
+    role: string;
+    config: ExecutionConfig;
+    policy: { name: string; allowed_imports?: string[]; denied_imports?: string[] };
+    profile?: ExecutionProfile;
+    runtime?: RuntimeImage;
+    image: string;
+}
+
+export interface RuntimeImage {
+    id: number;
+    name: string;
+    language: string;
+    image: string;
+    description: string;
+    packages: string;
+}
+
+export interface ClassRuntime {
+    id: number;
+    class_id: number;
+    language: string;
+    runtime_image_id: number;
+}
+
+export interface SimilarityRegion {
+    start_line_a: number;
+    end_line_a: number;
+    start_line_b: number;
+    end_line_b: number;
+    tokens: number;
+    code_a: string;
+    code_b: string;
+}
+
+export interface SimilarityPair {
+    group: string;
+    document_a: string;
+    document_b: string;
+    user_zcode_a: string;
+    user_zcode_b: string;
+    similarity: number;
+    coverage_a: number;
+    coverage_b: number;
+    matched_tokens: number;
+    regions: SimilarityRegion[];
+}
+
+export interface SimilarityReport {
+    lecture_id: number;
+    generated_at: string;
+    min_similarity: number;
+    pair_count: number;
+    documents: {
+        id: string;
+        source: string;
+        group: string;
+        title: string;
+        user_zcode: string;
+        language: string;
+        code: string;
+        submitted_at: string;
+    }[];
+    pairs: SimilarityPair[];
+}
+
+export interface PackageListing {
+    language: string;
+    image: string;
+    runtime?: RuntimeImage;
+    installed: { name: string; version: string }[];
+    listed_at: string;
+    allowed_imports?: string[];
+    denied_imports?: string[];
+}
 
 export const classroomService = {
     async joinClassroom(
@@ -74,17 +234,18 @@ export const classroomService = {
         lectureId: number,
         userZcode: string,
         code: string,
-        userRole: 'teacher' | 'student'
+        userRole: 'teacher' | 'student',
+        language: string = 'python',
+        stdin?: string
     ): Promise<ExecutionResult> {
         try {
             const response = await instance.post('/api/execution/execute', {
                 lecture_id: lectureId,
                 user_zcode: userZcode,
                 code: code,
-                language: 'python',
+                language: language,
+                stdin: stdin,
                 document_key: userRole === 'teacher' ? 'teacher-code' : `student-${userZcode}`
-            }, {
-                params: { role: userRole }
             });
 
             if (response.data.success) {
@@ -99,4 +260,253 @@ export const classroomService = {
             throw new Error('Failed to execute code');
         }
     },
+    async executeProject(
+        lectureId: number,
+        userZcode: string,
+        files: WorkspaceFile[],
+        entrypoint: string,
+        userRole: 'teacher' | 'student',
+        language: string = 'python',
+        stdin?: string
+    ): Promise<ExecutionResult> {
+        try {
+            const response = await instance.post('/api/execution/execute', {
+                lecture_id: lectureId,
+                user_zcode: userZcode,
+                files: files,
+                entrypoint: entrypoint,
+                language: language,
+                stdin: stdin
+            });
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Code execution failed');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Failed to execute code');
+        }
+    },
+    async downloadArtifact(executionId: string, path: string): Promise<Blob> {
+        try {
+            const response = await instance.get(`/api/execution/result/${executionId}/artifact`, {
+                params: { path },
+                responseType: 'blob'
+            });
+            return response.data;
+        } catch (error: any) {
+            throw new Error('Failed to download artifact');
+        }
+    },
+    async cancelExecution(executionId: string): Promise<ExecutionResult> {
+        try {
+            const response = await instance.post(`/api/execution/cancel/${executionId}`);
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to cancel execution');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+    async getLectureExecutionStats(lectureId: number): Promise<StudentExecutionStats[]> {
+        try {
+            const response = await instance.get(`/api/execution/lecture/${lectureId}/stats`);
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to get execution statistics');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+    async getSupportedLanguages(): Promise<SupportedLanguage[]> {
+        try {
+            const response = await instance.get('/api/execution/languages');
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to get supported languages');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+    async getExecutionProfiles(classId: number): Promise<ExecutionProfile[]> {
+        try {
+            const response = await instance.get('/api/execution/profiles', {
+                params: { class_id: classId }
+            });
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to get execution profiles');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+    async saveExecutionProfile(profile: ExecutionProfileRequest, profileId?: number): Promise<ExecutionProfile> {
+        try {
+            const url = profileId ? `/api/execution/profile/update/${profileId}` : '/api/execution/profile/create';
+            const response = await instance.post(url, profile);
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to save execution profile');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+    async deleteExecutionProfile(profileId: number): Promise<void> {
+        try {
+            const response = await instance.post(`/api/execution/profile/delete/${profileId}`);
+
+            if (!response.data.success) {
+                throw new Error(response.data.error || 'Failed to delete execution profile');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+    async resolveExecutionProfile(lectureId: number, language: string = 'python'): Promise<ResolvedExecutionProfile> {
+        try {
+            const response = await instance.get('/api/execution/profile/resolve', {
+                params: { lecture_id: lectureId, language }
+            });
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to resolve execution profile');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+
+    async getRuntimeImages(): Promise<RuntimeImage[]> {
+        try {
+            const response = await instance.get('/api/execution/runtimes');
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to get runtime images');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+
+    async getClassRuntimes(classId: number): Promise<ClassRuntime[]> {
+        try {
+            const response = await instance.get(`/api/execution/runtime/class/${classId}`);
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to get class runtimes');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+
+    async setClassRuntime(classId: number, language: string, runtimeImageId: number): Promise<ClassRuntime | undefined> {
+        try {
+            const response = await instance.post(`/api/execution/runtime/class/${classId}`, {
+                language,
+                runtime_image_id: runtimeImageId
+            });
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to set class runtime');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+
+    async getPackages(lectureId: number, language: string = 'python'): Promise<PackageListing> {
+        try {
+            const response = await instance.get('/api/execution/packages', {
+                params: { lecture_id: lectureId, language }
+            });
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to get packages');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
+
+    async getSimilarityReport(lectureId: number, options: { source?: string; exercise_id?: number; language?: string; min_similarity?: number; limit?: number } = {}): Promise<SimilarityReport> {
+        try {
+            const response = await instance.get(`/api/similarity/lecture/${lectureId}`, {
+                params: options
+            });
+
+            if (response.data.success) {
+                return response.data.data;
+            } else {
+                throw new Error(response.data.error || 'Failed to get similarity report');
+            }
+        } catch (error: any) {
+            if (error.response?.data?.error) {
+                throw new Error(error.response.data.error);
+            }
+            throw new Error('Network error');
+        }
+    },
 };
\ No newline at end of file
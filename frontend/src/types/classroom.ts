@@ -30,9 +30,13 @@ export interface ChatMessage {
 export interface JoinClassroomResponse {
     lecture_id: number
     lecture_name:string
+    lecturer_zcode: string
+    start_time: string | null
+    end_time: string | null
     user_zcode: string
     user_name: string
     user_role: 'teacher' | 'student'
+    class_role: string
     websocket_url: string
     message: string
 }